@@ -2,26 +2,40 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	nethttp "net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/glwbr/brisa/document"
+	"github.com/glwbr/brisa/internal/http"
 	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
 	"github.com/glwbr/brisa/portal/ba"
+	nfexml "github.com/glwbr/brisa/portal/xml"
 	"github.com/glwbr/brisa/scraper"
 	"github.com/glwbr/brisa/server"
 )
 
 func main() {
 	mode := flag.String("mode", "parse", "Mode: 'parse' (from file), 'scrape' (from portal), or 'server' (http api)")
-	portal := flag.String("portal", "BA", "NFC-e portal (e.g. BA)")
-	file := flag.String("file", "", "Path to HTML file to parse (parse mode)")
+	file := flag.String("file", "", "Path to NFe tab HTML or NF-e XML file to parse (parse mode)")
 	key := flag.String("key", "", "NFC-e access key (scrape mode)")
 	output := flag.String("output", "", "Output directory for scraped HTML (scrape mode)")
 	captchaFile := flag.String("captcha-output", "captcha.png", "Path to save captcha image (scrape mode)")
 	addr := flag.String("addr", ":8080", "Server address (server mode)")
+	insecure := flag.Bool("insecure", false, "Skip TLS certificate verification (scrape mode, debugging only)")
+	record := flag.String("record", "", "Record the portal traffic to this cassette file (scrape mode)")
+	replay := flag.String("replay", "", "Replay the portal traffic from this cassette file instead of the network (scrape mode)")
+	harFile := flag.String("har", "", "Write the portal traffic to this HAR file (scrape mode)")
+	harRedact := flag.Bool("har-redact", true, "Hide the access key and captcha answers in the HAR file")
+	sessions := flag.String("sessions", defaultSessionDir(), "Directory to keep portal sessions in between runs, empty to disable (scrape mode)")
+	statusOnly := flag.Bool("status", false, "Only recheck the invoice's status, e.g. whether it was cancelled (scrape mode)")
 
 	flag.Parse()
 
@@ -34,29 +48,43 @@ func main() {
 		if *file == "" {
 			log.Fatal("missing --file for parse mode")
 		}
-		runParseMode(*portal, *file)
+		runParseMode(*file)
 	case "scrape":
 		if *key == "" {
 			log.Fatal("missing --key for scrape mode")
 		}
-		runScrapeMode(*portal, *key, *output, *captchaFile)
+		if *record != "" && *replay != "" {
+			log.Fatal("--record and --replay are mutually exclusive")
+		}
+		runScrapeMode(*key, scrapeOptions{
+			outputDir:   *output,
+			captchaFile: *captchaFile,
+			insecure:    *insecure,
+			record:      *record,
+			replay:      *replay,
+			har:         *harFile,
+			harRedact:   *harRedact,
+			sessionDir:  *sessions,
+			statusOnly:  *statusOnly,
+		})
 	default:
 		log.Fatalf("unknown mode: %s", *mode)
 	}
 }
 
-func runParseMode(portalName, filePath string) {
-	if portalName != "BA" {
-		log.Fatalf("unsupported portal: %s", portalName)
-	}
-
-	html, err := os.ReadFile(filePath)
+func runParseMode(filePath string) {
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		log.Fatalf("read file: %v", err)
 	}
 
-	// For now, assuming we are parsing the NFe tab HTML
-	receipt, err := ba.ParseNFeTab(html)
+	var receipt *invoice.Receipt
+	if nfexml.IsNFe(data) {
+		receipt, err = nfexml.Parse(data)
+	} else {
+		// Anything else is assumed to be the portal's NFe tab HTML.
+		receipt, err = ba.ParseNFeTab(data)
+	}
 	if err != nil {
 		log.Fatalf("parse receipt: %v", err)
 	}
@@ -64,46 +92,110 @@ func runParseMode(portalName, filePath string) {
 	printReceipt(receipt)
 }
 
-func runScrapeMode(portalName, accessKey, outputDir, captchaFile string) {
-	if portalName != "BA" {
-		log.Fatalf("unsupported portal: %s", portalName)
-	}
+type scrapeOptions struct {
+	outputDir   string
+	captchaFile string
+	insecure    bool
+	// record and replay are cassette paths; see http.Cassette.
+	record string
+	replay string
+	// har is the path of a HAR capture of the session.
+	har       string
+	harRedact bool
+	// sessionDir keeps portal sessions for the next run; see
+	// scraper.FileSessionStore.
+	sessionDir string
+	// statusOnly rechecks the invoice's status instead of fetching it.
+	statusOnly bool
+}
 
+func runScrapeMode(accessKey string, opts scrapeOptions) {
 	ctx := context.Background()
 
-	s, err := ba.New(ba.WithCaptchaSolver(&scraper.ManualSolver{
-		PromptFunc: func(_ context.Context, challenge *scraper.CaptchaChallenge) (string, error) {
-			if err := os.WriteFile(captchaFile, challenge.Image, 0644); err != nil {
+	key, err := invoice.ParseAccessKey(accessKey)
+	if err != nil {
+		log.Fatalf("invalid access key: %v", err)
+	}
+
+	var har *http.HARWriter
+	if opts.har != "" {
+		har = http.NewHARWriter()
+		if opts.harRedact {
+			har.Redact(key.String(), key.Formatted())
+		}
+	}
+
+	cfg := scraper.Config{
+		Observer:           scraper.ObserverFunc(printProgress),
+		InsecureSkipVerify: opts.insecure,
+		CaptchaSolver: &scraper.ManualSolver{PromptFunc: func(_ context.Context, challenge *scraper.CaptchaChallenge) (string, error) {
+			fmt.Fprint(os.Stderr, "\r\033[K")
+			if err := os.WriteFile(opts.captchaFile, challenge.Image, 0644); err != nil {
 				return "", fmt.Errorf("save captcha: %w", err)
 			}
-			fmt.Printf("Captcha saved to: %s\n", captchaFile)
+			fmt.Printf("Captcha saved to: %s\n", opts.captchaFile)
 			fmt.Print("Enter captcha solution: ")
 
 			var solution string
 			if _, err := fmt.Scanln(&solution); err != nil {
 				return "", fmt.Errorf("read input: %w", err)
 			}
+			if har != nil && opts.harRedact {
+				har.Redact(solution)
+			}
 			return solution, nil
-		},
-	}))
+		}},
+	}
+
+	f, recording, sessions, err := openFetcher(key, opts, cfg, har)
 	if err != nil {
-		log.Fatalf("failed to create scraper: %v", err)
+		log.Fatal(err)
 	}
 
-	fmt.Printf("Fetching invoice: %s\n", accessKey)
+	if opts.statusOnly {
+		checkStatus(ctx, key, f)
+		if sessions != nil {
+			sessions.save()
+		}
+		return
+	}
 
-	result, err := s.FetchByAccessKey(ctx, accessKey)
+	fmt.Printf("Fetching invoice: %s\n", key.Formatted())
 
+	result, err := f.FetchByAccessKey(ctx, key.String())
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	if sessions != nil {
+		sessions.save()
+	}
+	if recording != nil {
+		// Failed sessions are worth keeping too.
+		if err := recording.Save(opts.record); err != nil {
+			log.Printf("warning: save cassette: %v", err)
+		} else {
+			fmt.Printf("Recorded %d requests to: %s\n", len(recording.Interactions), opts.record)
+		}
+	}
+	if har != nil {
+		if err := writeHAR(har, opts.har); err != nil {
+			log.Printf("warning: save HAR: %v", err)
+		} else {
+			fmt.Printf("Wrote %d requests to: %s\n", har.Len(), opts.har)
+		}
+	}
 	if err != nil {
 		log.Fatalf("fetch invoice: %v", err)
 	}
+	fmt.Printf("Fetched in %d attempt(s), %s\n", result.Attempts, result.Elapsed.Round(time.Millisecond))
+	for _, w := range result.Warnings {
+		log.Printf("warning: %v", w)
+	}
 
-	if outputDir != "" {
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
+	if opts.outputDir != "" {
+		if err := os.MkdirAll(opts.outputDir, 0755); err != nil {
 			log.Fatalf("create output dir: %v", err)
 		}
 		for name, content := range result.RawHTML {
-			path := fmt.Sprintf("%s/%s.html", outputDir, name)
+			path := fmt.Sprintf("%s/%s.html", opts.outputDir, name)
 			if err := os.WriteFile(path, content, 0644); err != nil {
 				log.Printf("warning: save %s: %v", name, err)
 			} else {
@@ -115,8 +207,174 @@ func runScrapeMode(portalName, accessKey, outputDir, captchaFile string) {
 	printReceipt(result.Receipt)
 }
 
+// openFetcher builds the fetcher for the key's portal, recording or
+// replaying its traffic as opts ask, and resumes the saved portal session.
+// Sessions are neither restored nor saved around a cassette: a recording
+// must start from a fresh session to replay, and replayed traffic has no
+// session worth keeping.
+func openFetcher(key invoice.AccessKey, opts scrapeOptions, cfg scraper.Config, har *http.HARWriter) (scraper.Fetcher, *http.Cassette, *sessionStore, error) {
+	var recording *http.Cassette
+	switch {
+	case opts.record != "":
+		recording = &http.Cassette{}
+		cfg.WrapTransport = recording.Recorder
+	case opts.replay != "":
+		cassette, err := http.LoadCassette(opts.replay)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("load cassette: %w", err)
+		}
+		cfg.WrapTransport = func(nethttp.RoundTripper) nethttp.RoundTripper {
+			return cassette.Replayer(ba.FieldAccessKey)
+		}
+		// The recorded answer is served whatever is submitted.
+		cfg.CaptchaSolver = &scraper.ManualSolver{PromptFunc: func(context.Context, *scraper.CaptchaChallenge) (string, error) {
+			return "replay", nil
+		}}
+	}
+
+	if har != nil {
+		wrap := cfg.WrapTransport
+		cfg.WrapTransport = func(rt nethttp.RoundTripper) nethttp.RoundTripper {
+			if wrap != nil {
+				rt = wrap(rt)
+			}
+			return har.Transport(rt)
+		}
+	}
+
+	f, err := scraper.NewForAccessKey(key, cfg)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create scraper: %w", err)
+	}
+
+	var sessions *sessionStore
+	if opts.sessionDir != "" && opts.record == "" && opts.replay == "" {
+		sessions = openSessionStore(opts.sessionDir, key, f)
+	}
+	return f, recording, sessions, nil
+}
+
+// checkStatus prints the current status and events of the invoice.
+func checkStatus(ctx context.Context, key invoice.AccessKey, f scraper.Fetcher) {
+	checker, ok := f.(scraper.StatusChecker)
+	if !ok {
+		log.Fatalf("the portal for %s cannot recheck invoice status", key.State())
+	}
+
+	fmt.Printf("Checking invoice: %s\n", key.Formatted())
+	status, events, err := checker.CheckStatus(ctx, key.String())
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	if err != nil {
+		log.Fatalf("check status: %v", err)
+	}
+
+	printStatus(status)
+	printEvents(events)
+}
+
+func writeHAR(har *http.HARWriter, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := har.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// defaultSessionDir returns the directory sessions are kept in by default,
+// or "" when the user has no cache directory.
+func defaultSessionDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "brisa", "sessions")
+}
+
+// sessionStore resumes a fetcher's portal session from disk and saves it
+// back once the fetch is done.
+type sessionStore struct {
+	store   scraper.FileSessionStore
+	name    string
+	fetcher scraper.Resumable
+}
+
+// openSessionStore restores the session saved for the key's portal into f,
+// unless it has expired. It returns nil if f cannot resume sessions.
+func openSessionStore(dir string, key invoice.AccessKey, f scraper.Fetcher) *sessionStore {
+	resumable, ok := f.(scraper.Resumable)
+	if !ok {
+		return nil
+	}
+	portal, err := scraper.PortalFor(key.UF)
+	if err != nil {
+		return nil
+	}
+	s := &sessionStore{
+		store:   scraper.FileSessionStore{Dir: dir},
+		name:    strings.ToLower(string(portal)),
+		fetcher: resumable,
+	}
+
+	snap, err := s.store.Load(s.name)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		log.Printf("warning: load session: %v", err)
+	case snap.Expired(time.Now()):
+		s.store.Delete(s.name)
+	default:
+		if err := resumable.Restore(snap); err != nil {
+			log.Printf("warning: restore session: %v", err)
+		} else {
+			fmt.Printf("Resuming session from %s\n", snap.CreatedAt.Format(time.Kitchen))
+		}
+	}
+	return s
+}
+
+func (s *sessionStore) save() {
+	snap := s.fetcher.Snapshot()
+	var err error
+	if snap == nil {
+		err = s.store.Delete(s.name)
+	} else {
+		err = s.store.Save(s.name, snap)
+	}
+	if err != nil {
+		log.Printf("warning: save session: %v", err)
+	}
+}
+
+// printProgress keeps a single status line on stderr up to date with the
+// stage in progress. Failed stages are kept on their own line.
+func printProgress(e scraper.Event) {
+	switch {
+	case e.Stage == scraper.StageRetry:
+		fmt.Fprintf(os.Stderr, "\r\033[Kattempt %d failed: %v; retrying\n", e.Attempt, e.Err)
+	case e.Phase == scraper.PhaseStarted:
+		fmt.Fprintf(os.Stderr, "\r\033[K[attempt %d] %s...", e.Attempt, e.Stage)
+	case e.Err != nil:
+		fmt.Fprintf(os.Stderr, "\r\033[K[attempt %d] %s failed after %s: %v\n",
+			e.Attempt, e.Stage, e.Duration.Round(time.Millisecond), e.Err)
+	default:
+		status := ""
+		if e.StatusCode != 0 {
+			status = fmt.Sprintf(", HTTP %d", e.StatusCode)
+		}
+		fmt.Fprintf(os.Stderr, "\r\033[K[attempt %d] %s done (%s%s)",
+			e.Attempt, e.Stage, e.Duration.Round(time.Millisecond), status)
+	}
+}
+
 func printReceipt(r *invoice.Receipt) {
 	fmt.Printf("\n=== Invoice Details ===\n\n")
+	if r.Cancelled() {
+		fmt.Printf("*** CANCELLED: this invoice was cancelled after issue and is not spending ***\n\n")
+	}
 	fmt.Printf("Key: %s\n", r.Key)
 	fmt.Printf("Portal: %s\n", r.Portal)
 
@@ -125,20 +383,42 @@ func printReceipt(r *invoice.Receipt) {
 	}
 
 	fmt.Printf("Series: %s, Number: %s\n", r.Series, r.ReceiptNumber)
+	if r.Status != "" {
+		printStatus(r.Status)
+	}
+	if a := r.Authorization; a != nil && a.Protocol != "" {
+		fmt.Printf("Protocol: %s (%s)", a.Protocol, a.AuthorizedAt.Format(time.RFC3339))
+		if a.Contingency() {
+			fmt.Print(", issued in contingency")
+		}
+		fmt.Println()
+	}
 
 	fmt.Println("\nIssuer:")
 	fmt.Printf("  Name: %s\n", r.Issuer.Name)
-	fmt.Printf("  CNPJ: %s\n", r.Issuer.CNPJ)
+	if r.Issuer.TradeName != "" {
+		fmt.Printf("  Trade Name: %s\n", r.Issuer.TradeName)
+	}
+	fmt.Printf("  CNPJ: %s\n", formatDocument(r.Issuer.CNPJ, false))
 	if r.Issuer.StateRegID != "" {
 		fmt.Printf("  State Reg ID: %s\n", r.Issuer.StateRegID)
 	}
-	if r.Issuer.Address.State != "" {
-		fmt.Printf("  State: %s\n", r.Issuer.Address.State)
+	if addr := r.Issuer.Address; addr.Street != "" {
+		fmt.Printf("  Address: %s, %s", addr.Street, addr.Number)
+		if addr.Complement != "" {
+			fmt.Printf(" - %s", addr.Complement)
+		}
+		fmt.Printf(", %s\n", addr.District)
+	}
+	if addr := r.Issuer.Address; addr.City != "" {
+		fmt.Printf("  City: %s/%s\n", addr.City, addr.State)
+	} else if addr.State != "" {
+		fmt.Printf("  State: %s\n", addr.State)
 	}
 
 	fmt.Println("\nConsumer:")
 	if r.Consumer.Document != "" {
-		fmt.Printf("  Document: %s\n", r.Consumer.Document)
+		fmt.Printf("  Document: %s\n", formatDocument(r.Consumer.Document, true))
 	}
 	if r.Consumer.Name != "" {
 		fmt.Printf("  Name: %s\n", r.Consumer.Name)
@@ -146,7 +426,34 @@ func printReceipt(r *invoice.Receipt) {
 
 	fmt.Printf("\nSubtotal: %s\n", r.Subtotal.String())
 	fmt.Printf("Discount: %s\n", r.Discount.String())
+	for _, c := range []struct {
+		name   string
+		amount money.BRL
+	}{{"Freight", r.Freight}, {"Insurance", r.Insurance}, {"Other Costs", r.OtherCosts}} {
+		if c.amount != 0 {
+			fmt.Printf("%s: %s\n", c.name, c.amount.String())
+		}
+	}
 	fmt.Printf("Total: %s\n", r.Total.String())
+	if tt := r.TaxTotals; tt != nil {
+		fmt.Printf("Taxes: ICMS %s, PIS %s, COFINS %s\n", tt.ICMS.String(), tt.PIS.String(), tt.COFINS.String())
+	}
+
+	if len(r.Payments) > 0 {
+		fmt.Println("\nPayments:")
+		for _, p := range r.Payments {
+			fmt.Printf("  %s: %s", p.Method, p.Amount.String())
+			if c := p.Card; c != nil {
+				fmt.Printf(" (%s, auth %s)", c.Brand, c.Authorization)
+			}
+			fmt.Println()
+		}
+		if r.Change != 0 {
+			fmt.Printf("  Change: %s\n", r.Change.String())
+		}
+	}
+
+	printEvents(r.Events)
 
 	fmt.Println("\nItems:")
 	if len(r.Items) == 0 {
@@ -158,4 +465,46 @@ func printReceipt(r *invoice.Receipt) {
 				item.Quantity, item.Unit, item.UnitPrice.String(), item.Total.String())
 		}
 	}
+
+	if findings := invoice.Validate(r); len(findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, f := range findings {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+}
+
+func printStatus(status invoice.Status) {
+	if status == "" {
+		status = "unknown"
+	}
+	fmt.Printf("Status: %s\n", status)
+}
+
+func printEvents(events []invoice.Event) {
+	if len(events) == 0 {
+		return
+	}
+	fmt.Println("\nEvents:")
+	for _, e := range events {
+		fmt.Printf("  %s: %s (protocol %s)\n", e.At.Format(time.RFC3339), e.Description, e.Protocol)
+	}
+}
+
+// formatDocument pretty-prints a valid CPF or CNPJ, optionally masked for
+// display, and returns anything else unchanged.
+func formatDocument(s string, mask bool) string {
+	if cpf, err := document.ParseCPF(s); err == nil {
+		if mask {
+			return cpf.Masked()
+		}
+		return cpf.Formatted()
+	}
+	if cnpj, err := document.ParseCNPJ(s); err == nil {
+		if mask {
+			return cnpj.Masked()
+		}
+		return cnpj.Formatted()
+	}
+	return s
 }
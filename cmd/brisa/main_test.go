@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/portal/ba"
+	"github.com/glwbr/brisa/portal/ba/batest"
+	"github.com/glwbr/brisa/scraper"
+)
+
+func TestRecordReplayWithSessions(t *testing.T) {
+	// The fake portal needs no politeness.
+	ba.SetRateLimit(ba.RateLimit{})
+	t.Cleanup(func() { ba.SetRateLimit(ba.DefaultRateLimit) })
+
+	srv, err := batest.NewServer(os.DirFS("../../testdata"))
+	if err != nil {
+		t.Fatalf("batest.NewServer() error = %v", err)
+	}
+	t.Cleanup(srv.Close)
+	key, err := invoice.ParseAccessKey(srv.AccessKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A session saved by an earlier run, which the recording must not resume.
+	sessionDir := t.TempDir()
+	s, err := ba.New(ba.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetCaptcha(context.Background()); err != nil {
+		t.Fatalf("GetCaptcha() error = %v", err)
+	}
+	portal, err := scraper.PortalFor(key.UF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := scraper.FileSessionStore{Dir: sessionDir}
+	if err := store.Save(strings.ToLower(string(portal)), s.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := scraper.Config{
+		BaseURL: srv.URL,
+		CaptchaSolver: &scraper.ManualSolver{PromptFunc: func(context.Context, *scraper.CaptchaChallenge) (string, error) {
+			return batest.CaptchaAnswer, nil
+		}},
+	}
+	cassette := filepath.Join(t.TempDir(), "ba.json")
+
+	f, recording, sessions, err := openFetcher(key, scrapeOptions{record: cassette, sessionDir: sessionDir}, cfg, nil)
+	if err != nil {
+		t.Fatalf("openFetcher() record error = %v", err)
+	}
+	if sessions != nil {
+		t.Error("openFetcher() resumed the saved session while recording")
+	}
+	if _, err := f.FetchByAccessKey(context.Background(), key.String()); err != nil {
+		t.Fatalf("FetchByAccessKey() recording error = %v", err)
+	}
+	if err := recording.Save(cassette); err != nil {
+		t.Fatal(err)
+	}
+
+	f, _, sessions, err = openFetcher(key, scrapeOptions{replay: cassette, sessionDir: sessionDir}, cfg, nil)
+	if err != nil {
+		t.Fatalf("openFetcher() replay error = %v", err)
+	}
+	if sessions != nil {
+		t.Error("openFetcher() resumed the saved session while replaying")
+	}
+	result, err := f.FetchByAccessKey(context.Background(), key.String())
+	if err != nil {
+		t.Fatalf("FetchByAccessKey() replay error = %v", err)
+	}
+	if result.Receipt.Key != key.String() {
+		t.Errorf("replayed receipt key = %q, want %q", result.Receipt.Key, key.String())
+	}
+}
@@ -0,0 +1,45 @@
+package document
+
+// CNPJ is a validated 14-character company taxpayer number, stored
+// unformatted. Since July 2026 the first 12 characters may be uppercase
+// letters; the two check digits are always numeric.
+type CNPJ string
+
+// ParseCNPJ validates s, which may be formatted, and returns it with the
+// formatting removed and letters uppercased.
+func ParseCNPJ(s string) (CNPJ, error) {
+	s = Clean(s)
+	if err := validate(s, 14, true); err != nil {
+		return "", err
+	}
+	return CNPJ(s), nil
+}
+
+func (c CNPJ) String() string { return string(c) }
+
+// IsAlphanumeric reports whether c uses the 2026 alphanumeric format.
+func (c CNPJ) IsAlphanumeric() bool {
+	for i := 0; i < len(c); i++ {
+		if c[i] < '0' || c[i] > '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// Formatted returns the CNPJ as 00.000.000/0000-00.
+func (c CNPJ) Formatted() string {
+	if len(c) != 14 {
+		return string(c)
+	}
+	return format(string(c), "00.000.000/0000-00")
+}
+
+// Masked returns the CNPJ with only the root digits after the first two
+// visible (**.345.678/****-**).
+func (c CNPJ) Masked() string {
+	if len(c) != 14 {
+		return string(c)
+	}
+	return format(hide(string(c), 2, 8), "00.000.000/0000-00")
+}
@@ -0,0 +1,32 @@
+package document
+
+// CPF is a validated 11-digit individual taxpayer number, stored unformatted.
+type CPF string
+
+// ParseCPF validates s, which may be formatted, and returns the bare digits.
+func ParseCPF(s string) (CPF, error) {
+	s = Clean(s)
+	if err := validate(s, 11, false); err != nil {
+		return "", err
+	}
+	return CPF(s), nil
+}
+
+func (c CPF) String() string { return string(c) }
+
+// Formatted returns the CPF as 000.000.000-00.
+func (c CPF) Formatted() string {
+	if len(c) != 11 {
+		return string(c)
+	}
+	return format(string(c), "000.000.000-00")
+}
+
+// Masked returns the CPF with only the middle six digits visible
+// (***.456.789-**), the form recommended for display under the LGPD.
+func (c CPF) Masked() string {
+	if len(c) != 11 {
+		return string(c)
+	}
+	return format(hide(string(c), 3, 9), "000.000.000-00")
+}
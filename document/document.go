@@ -0,0 +1,115 @@
+// Package document validates and formats Brazilian taxpayer documents:
+// CPF for individuals and CNPJ for companies, including the alphanumeric
+// CNPJ introduced in July 2026.
+package document
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrLength     = errors.New("invalid document length")
+	ErrCharacter  = errors.New("invalid document character")
+	ErrRepeated   = errors.New("document has all characters repeated")
+	ErrCheckDigit = errors.New("invalid document check digits")
+)
+
+// Clean strips formatting (dots, slashes, dashes, spaces) from s and
+// uppercases it, keeping the letters of alphanumeric CNPJs.
+func Clean(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || r == '*' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// IsMasked reports whether s hides some of its characters, as portals do
+// for consumer documents.
+func IsMasked(s string) bool {
+	return strings.Contains(s, "*")
+}
+
+// IsZero reports whether s is the all-zero placeholder portals show for
+// unidentified consumers ("000.000.000-00").
+func IsZero(s string) bool {
+	s = Clean(s)
+	return s != "" && strings.Trim(s, "0") == ""
+}
+
+// validate checks s against the layout of a document with the given length
+// and computes its two mod-11 check digits. Only the base (all but the last
+// two characters) may contain letters when alnum is set.
+func validate(s string, length int, alnum bool) error {
+	if len(s) != length {
+		return fmt.Errorf("%w: got %d characters, want %d", ErrLength, len(s), length)
+	}
+	base := length - 2
+	for i := 0; i < length; i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' || alnum && i < base && c >= 'A' && c <= 'Z' {
+			continue
+		}
+		return fmt.Errorf("%w: %q at position %d", ErrCharacter, c, i+1)
+	}
+	if strings.Count(s, s[:1]) == length {
+		return ErrRepeated
+	}
+
+	first := checkDigit(s[:base], alnum)
+	second := checkDigit(s[:base]+string(rune('0'+first)), alnum)
+	if int(s[base]-'0') != first || int(s[base+1]-'0') != second {
+		return ErrCheckDigit
+	}
+	return nil
+}
+
+// checkDigit computes a mod-11 check digit. CPF weights run 2, 3, ... from
+// the right without bound; CNPJ weights cycle through 2..9. Characters are
+// valued by their ASCII code minus 48, so digits keep their value and 'A'
+// is 17, per the alphanumeric CNPJ specification.
+func checkDigit(s string, cycle bool) int {
+	sum, weight := 0, 2
+	for i := len(s) - 1; i >= 0; i-- {
+		sum += int(s[i]-'0') * weight
+		weight++
+		if cycle && weight > 9 {
+			weight = 2
+		}
+	}
+	if r := sum % 11; r >= 2 {
+		return 11 - r
+	}
+	return 0
+}
+
+// format applies a mask such as "000.000.000-00" to s, replacing each '0'
+// with the next character of s.
+func format(s, mask string) string {
+	var b strings.Builder
+	i := 0
+	for _, m := range mask {
+		if m == '0' {
+			b.WriteByte(s[i])
+			i++
+		} else {
+			b.WriteRune(m)
+		}
+	}
+	return b.String()
+}
+
+// hide replaces the characters of s outside [from, to) with '*'.
+func hide(s string, from, to int) string {
+	b := []byte(s)
+	for i := range b {
+		if i < from || i >= to {
+			b[i] = '*'
+		}
+	}
+	return string(b)
+}
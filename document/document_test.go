@@ -0,0 +1,92 @@
+package document
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCPF(t *testing.T) {
+	tests := []struct {
+		input string
+		want  CPF
+		err   error
+	}{
+		{input: "123.456.789-09", want: "12345678909"},
+		{input: "12345678909", want: "12345678909"},
+		{input: "123.456.789-08", err: ErrCheckDigit},
+		{input: "111.111.111-11", err: ErrRepeated},
+		{input: "1234567890", err: ErrLength},
+		{input: "1234567890A", err: ErrCharacter},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPF(tt.input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseCPF(%q) error = %v, want %v", tt.input, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCPF(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseCNPJ(t *testing.T) {
+	tests := []struct {
+		input string
+		want  CNPJ
+		alnum bool
+		err   error
+	}{
+		{input: "06.057.223/0314-84", want: "06057223031484"},
+		// Example from the Receita Federal alphanumeric CNPJ specification.
+		{input: "12.ABC.345/01DE-35", want: "12ABC34501DE35", alnum: true},
+		{input: "12.abc.345/01de-35", want: "12ABC34501DE35", alnum: true},
+		{input: "12.ABC.345/01DE-36", err: ErrCheckDigit},
+		{input: "12.ABC.345/01DE-3A", err: ErrCharacter},
+		{input: "00.000.000/0000-00", err: ErrRepeated},
+		{input: "06.057.223/0314", err: ErrLength},
+	}
+	for _, tt := range tests {
+		got, err := ParseCNPJ(tt.input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseCNPJ(%q) error = %v, want %v", tt.input, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCNPJ(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if err == nil && got.IsAlphanumeric() != tt.alnum {
+			t.Errorf("ParseCNPJ(%q).IsAlphanumeric() = %v", tt.input, !tt.alnum)
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	cpf := CPF("12345678909")
+	if got := cpf.Formatted(); got != "123.456.789-09" {
+		t.Errorf("CPF.Formatted() = %q", got)
+	}
+	if got := cpf.Masked(); got != "***.456.789-**" {
+		t.Errorf("CPF.Masked() = %q", got)
+	}
+
+	cnpj := CNPJ("12ABC34501DE35")
+	if got := cnpj.Formatted(); got != "12.ABC.345/01DE-35" {
+		t.Errorf("CNPJ.Formatted() = %q", got)
+	}
+	if got := cnpj.Masked(); got != "**.ABC.345/****-**" {
+		t.Errorf("CNPJ.Masked() = %q", got)
+	}
+}
+
+func TestCleanAndPlaceholders(t *testing.T) {
+	if got := Clean(" 12.abc.345/01de-35 "); got != "12ABC34501DE35" {
+		t.Errorf("Clean() = %q", got)
+	}
+	if !IsZero("000.000.000-00") || IsZero("") || IsZero("123.456.789-09") {
+		t.Error("IsZero() misclassified placeholder")
+	}
+	if !IsMasked("***.456.789-**") || IsMasked("123.456.789-09") {
+		t.Error("IsMasked() misclassified")
+	}
+}
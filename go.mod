@@ -4,7 +4,11 @@ go 1.25.4
 
 require (
 	github.com/PuerkitoBio/goquery v1.11.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	golang.org/x/net v0.47.0
 )
 
-require github.com/andybalholm/cascadia v1.3.3 // indirect
+require (
+	github.com/andybalholm/cascadia v1.3.3 // indirect
+	golang.org/x/text v0.31.0 // indirect
+)
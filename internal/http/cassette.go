@@ -0,0 +1,230 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Cassette holds recorded HTTP interactions. Record traffic into one with
+// Recorder, save it, and serve it back later with Replayer.
+type Cassette struct {
+	mu           sync.Mutex
+	Interactions []Interaction `json:"interactions"`
+	used         []bool
+}
+
+// Interaction is one request and the response it got. Cookies travel in
+// the Cookie and Set-Cookie headers.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	// Form holds url-encoded bodies; other bodies are kept in Body.
+	Form url.Values `json:"form,omitempty"`
+	Body []byte     `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette saved with Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decode cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the interactions recorded so far to path as JSON, readable by
+// the owner only since they hold session cookies and access keys.
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Recorder returns a round tripper that sends requests through base and
+// appends each exchange to c.
+func (c *Cassette) Recorder(base http.RoundTripper) http.RoundTripper {
+	return &recorder{base: base, cassette: c}
+}
+
+// Replayer returns a round tripper that answers from c without touching
+// the network. A request gets the first response not served yet whose
+// request had the same method, path and values for matchFields, taken
+// from the form or the query string. Other fields, such as captcha
+// answers and ASP.NET state, are ignored. Requests nothing matches fail
+// with ErrNoInteraction.
+func (c *Cassette) Replayer(matchFields ...string) http.RoundTripper {
+	return &replayer{cassette: c, fields: matchFields}
+}
+
+// WithRecorder records the client's traffic into c.
+func WithRecorder(c *Cassette) Option {
+	return WrapTransport(c.Recorder)
+}
+
+// WithReplay serves the client's requests from c; see Cassette.Replayer.
+func WithReplay(c *Cassette, matchFields ...string) Option {
+	return func(cl *Client) { cl.http.Transport = c.Replayer(matchFields...) }
+}
+
+// WrapTransport replaces the client's round tripper with wrap applied to
+// it, e.g. to record traffic.
+func WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) { c.http.Transport = wrap(c.Transport()) }
+}
+
+type recorder struct {
+	base     http.RoundTripper
+	cassette *Cassette
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, req, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c := r.cassette
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       body,
+		},
+	})
+	return resp, nil
+}
+
+type replayer struct {
+	cassette *Cassette
+	fields   []string
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, _, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c := r.cassette
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.used) != len(c.Interactions) {
+		c.used = make([]bool, len(c.Interactions))
+	}
+	for i, in := range c.Interactions {
+		if c.used[i] || !r.match(in.Request, recorded) {
+			continue
+		}
+		c.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w for %s %s", ErrNoInteraction, req.Method, req.URL.Path)
+}
+
+func (r *replayer) match(recorded, req RecordedRequest) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+	ru, err1 := url.Parse(recorded.URL)
+	qu, err2 := url.Parse(req.URL)
+	if err1 != nil || err2 != nil || ru.Path != qu.Path {
+		return false
+	}
+	for _, f := range r.fields {
+		if fieldValue(recorded.Form, ru, f) != fieldValue(req.Form, qu, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValue looks name up in form, then in the query string of u.
+func fieldValue(form url.Values, u *url.URL, name string) string {
+	if form.Has(name) {
+		return form.Get(name)
+	}
+	return u.Query().Get(name)
+}
+
+// recordRequest captures req, returning a request whose body can still be
+// sent.
+func recordRequest(req *http.Request) (RecordedRequest, *http.Request, error) {
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return recorded, req, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return recorded, nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			recorded.Form = form
+			return recorded, req, nil
+		}
+	}
+	recorded.Body = body
+	return recorded, req, nil
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCassetteRecordReplay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /page", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Write([]byte("form"))
+	})
+	mux.HandleFunc("POST /page", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s1" {
+			t.Errorf("POST without session cookie")
+		}
+		http.Redirect(w, r, "/result?key="+r.PostFormValue("key"), http.StatusFound)
+	})
+	mux.HandleFunc("GET /result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("result " + r.URL.Query().Get("key")))
+	})
+	srv := httptest.NewServer(mux)
+
+	run := func(c *Client, key string) (string, error) {
+		if _, err := c.Get(context.Background(), "/page", nil); err != nil {
+			return "", err
+		}
+		resp, err := c.PostForm(context.Background(), "/page", url.Values{"key": {key}, "captcha": {"abc"}}, nil)
+		if err != nil {
+			return "", err
+		}
+		body, err := resp.Body()
+		return string(body), err
+	}
+
+	cassette := &Cassette{}
+	c, err := New(srv.URL, WithRecorder(cassette))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"k1", "k2"} {
+		if _, err := run(c, key); err != nil {
+			t.Fatalf("recording %s: %v", key, err)
+		}
+	}
+	srv.Close()
+
+	if got := len(cassette.Interactions); got != 6 {
+		t.Fatalf("recorded %d interactions, want 6", got)
+	}
+	if form := cassette.Interactions[1].Request.Form; form.Get("key") != "k1" || form.Get("captcha") != "abc" {
+		t.Errorf("recorded form = %v", form)
+	}
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := cassette.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("cassette mode = %v, want 0600", perm)
+	}
+
+	loaded, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() error = %v", err)
+	}
+	c, err = New(srv.URL, WithReplay(loaded, "key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replayed out of order: the key field picks the matching POST.
+	for _, key := range []string{"k2", "k1"} {
+		got, err := run(c, key)
+		if err != nil {
+			t.Fatalf("replaying %s: %v", key, err)
+		}
+		if want := "result " + key; got != want {
+			t.Errorf("replayed body = %q, want %q", got, want)
+		}
+	}
+
+	if _, err := run(c, "k1"); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("replaying a used interaction error = %v, want %v", err, ErrNoInteraction)
+	}
+}
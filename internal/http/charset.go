@@ -0,0 +1,47 @@
+package http
+
+import (
+	"fmt"
+	"mime"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// DecodeHTML transcodes an HTML or plain text body to UTF-8. The charset
+// comes from contentType, a byte order mark or a <meta> tag, in that order;
+// undeclared bodies that are not valid UTF-8 are read as Windows-1252, the
+// superset of ISO-8859-1 browsers use. It returns the body unchanged, with
+// an empty charset name, for other media types.
+func DecodeHTML(body []byte, contentType string) ([]byte, string, error) {
+	if !isText(contentType) {
+		return body, "", nil
+	}
+
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" || (!certain && name == "windows-1252" && utf8.Valid(body)) {
+		return body, "utf-8", nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, name, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return decoded, name, nil
+}
+
+// isText reports whether contentType is HTML or plain text. A missing
+// content type counts as HTML.
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "text/html", "text/plain", "application/xhtml+xml":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDecodeHTML(t *testing.T) {
+	latin1 := []byte("<p>S\xe9rie / Destinat\xe1rio</p>")
+	utf8 := []byte("<p>Série / Destinatário</p>")
+	meta := `<meta http-equiv="Content-Type" content="text/html; charset=ISO-8859-1">`
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        []byte
+		wantCharset string
+	}{
+		{name: "header", body: latin1, contentType: "text/html; charset=iso-8859-1", want: utf8, wantCharset: "windows-1252"},
+		{name: "header_windows_1252", body: latin1, contentType: "text/html; charset=windows-1252", want: utf8, wantCharset: "windows-1252"},
+		{name: "meta", body: append([]byte(meta), latin1...), contentType: "text/html", want: append([]byte(meta), utf8...), wantCharset: "windows-1252"},
+		{name: "undeclared_latin1", body: latin1, want: utf8, wantCharset: "windows-1252"},
+		{name: "undeclared_utf8", body: utf8, contentType: "text/html", want: utf8, wantCharset: "utf-8"},
+		{name: "utf8_header", body: utf8, contentType: "text/html; charset=utf-8", want: utf8, wantCharset: "utf-8"},
+		{name: "image", body: latin1, contentType: "image/png", want: latin1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, name, err := DecodeHTML(tt.body, tt.contentType)
+			if err != nil {
+				t.Fatalf("DecodeHTML() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) || name != tt.wantCharset {
+				t.Errorf("DecodeHTML() = %q, %q, want %q, %q", got, name, tt.want, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestResponseBodyCharset(t *testing.T) {
+	page := []byte("<html><body>Chave de acesso inv\xe1lida</body></html>")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+		w.Write(page)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(context.Background(), "/", nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	body, err := resp.Body()
+	if err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	if !bytes.Contains(body, []byte("inválida")) {
+		t.Errorf("Body() = %q, want UTF-8", body)
+	}
+	if resp.Charset() != "windows-1252" {
+		t.Errorf("Charset() = %q, want windows-1252", resp.Charset())
+	}
+	raw, err := resp.RawBody()
+	if err != nil {
+		t.Fatalf("RawBody() error = %v", err)
+	}
+	if !bytes.Equal(raw, page) {
+		t.Errorf("RawBody() = %q, want %q", raw, page)
+	}
+}
@@ -3,7 +3,6 @@ package http
 
 import (
 	"context"
-	"crypto/tls"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
@@ -18,6 +17,7 @@ type Client struct {
 	http    *http.Client
 	baseURL string
 	headers map[string]string
+	limiter *Limiter
 }
 
 type Option func(*Client)
@@ -30,16 +30,14 @@ func WithHeader(key, value string) Option {
 	return func(c *Client) { c.headers[key] = value }
 }
 
-func WithInsecureSkipVerify() Option {
-	return func(c *Client) {
-		c.http.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-	}
+// WithTransport sets the round tripper used for requests, e.g. to share
+// connections between clients that keep separate cookie jars.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) { c.http.Transport = rt }
 }
 
 func New(baseURL string, opts ...Option) (*Client, error) {
-	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	jar, err := newJar()
 	if err != nil {
 		return nil, err
 	}
@@ -57,45 +55,116 @@ func New(baseURL string, opts ...Option) (*Client, error) {
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.limiter != nil {
+		c.http.Transport = &limitTransport{base: c.Transport(), limiter: c.limiter}
+	}
 	return c, nil
 }
 
+func newJar() (http.CookieJar, error) {
+	return cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+}
+
+// ResetCookies discards all cookies, starting a new server session on the
+// next request.
+func (c *Client) ResetCookies() error {
+	jar, err := newJar()
+	if err != nil {
+		return err
+	}
+	c.http.Jar = jar
+	return nil
+}
+
+// Cookies returns the cookies the client sends to its base URL.
+func (c *Client) Cookies() []*http.Cookie {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil
+	}
+	return c.http.Jar.Cookies(u)
+}
+
+// SetCookies stores cookies as if the base URL had set them.
+func (c *Client) SetCookies(cookies []*http.Cookie) error {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return err
+	}
+	c.http.Jar.SetCookies(u, cookies)
+	return nil
+}
+
 type RequestConfig struct {
 	Headers map[string]string
 	Params  url.Values
 	Referer string
+
+	// ExpectStatus lists the status codes the response may have; any other
+	// is returned as an *HTTPError. It defaults to any 2xx status.
+	ExpectStatus []int
+	// ExpectMediaType, when set, is the media type the response must have,
+	// such as "text/html" or "image/*"; anything else fails with
+	// ErrUnexpectedContentType.
+	ExpectMediaType string
+	// ExpectPaths, when set, lists the paths the response may be served
+	// from once redirects are followed; anything else fails with
+	// ErrUnexpectedRedirect.
+	ExpectPaths []string
 }
 
 type Response struct {
 	*http.Response
-	body []byte
+	raw     []byte
+	body    []byte
+	charset string
 }
 
+// Body returns the response body. HTML and plain text bodies are
+// transcoded to UTF-8; see DecodeHTML.
 func (r *Response) Body() ([]byte, error) {
 	if r.body != nil {
 		return r.body, nil
 	}
-	defer r.Response.Body.Close()
-	body, err := io.ReadAll(r.Response.Body)
+	raw, err := r.RawBody()
+	if err != nil {
+		return nil, err
+	}
+	body, name, err := DecodeHTML(raw, r.Header.Get("Content-Type"))
 	if err != nil {
 		return nil, err
 	}
-	r.body = body
+	r.body, r.charset = body, name
 	return r.body, nil
 }
 
-func (c *Client) Get(ctx context.Context, path string, cfg *RequestConfig) (*Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.buildURL(path, cfg), nil)
+// RawBody returns the body as received, before any transcoding, e.g. for
+// archiving.
+func (r *Response) RawBody() ([]byte, error) {
+	if r.raw != nil {
+		return r.raw, nil
+	}
+	defer r.Response.Body.Close()
+	raw, err := io.ReadAll(r.Response.Body)
 	if err != nil {
 		return nil, err
 	}
-	c.applyHeaders(req, cfg)
+	r.raw = raw
+	return r.raw, nil
+}
 
-	resp, err := c.http.Do(req)
+// Charset returns the name of the charset Body decoded, or "" before Body
+// is called and for non-text bodies.
+func (r *Response) Charset() string { return r.charset }
+
+func (c *Client) Get(ctx context.Context, path string, cfg *RequestConfig) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.buildURL(path, cfg), nil)
 	if err != nil {
 		return nil, err
 	}
-	return &Response{Response: resp}, nil
+	c.applyHeaders(req, cfg)
+
+	return c.do(req, cfg)
 }
 
 func (c *Client) PostForm(ctx context.Context, path string, data url.Values, cfg *RequestConfig) (*Response, error) {
@@ -106,19 +175,35 @@ func (c *Client) PostForm(ctx context.Context, path string, data url.Values, cfg
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	c.applyHeaders(req, cfg)
 
-	resp, err := c.http.Do(req)
+	return c.do(req, cfg)
+}
+
+func (c *Client) do(req *http.Request, cfg *RequestConfig) (*Response, error) {
+	httpResp, err := c.http.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	return &Response{Response: resp}, nil
+	resp := &Response{Response: httpResp}
+	if err := check(resp, cfg); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
+// GetImage fetches an image; cfg.ExpectMediaType defaults to "image/*".
 func (c *Client) GetImage(ctx context.Context, path string, cfg *RequestConfig) ([]byte, string, error) {
-	resp, err := c.Get(ctx, path, cfg)
+	imageCfg := RequestConfig{ExpectMediaType: "image/*"}
+	if cfg != nil {
+		imageCfg = *cfg
+		if imageCfg.ExpectMediaType == "" {
+			imageCfg.ExpectMediaType = "image/*"
+		}
+	}
+	resp, err := c.Get(ctx, path, &imageCfg)
 	if err != nil {
 		return nil, "", err
 	}
-	body, err := resp.Body()
+	body, err := resp.RawBody()
 	if err != nil {
 		return nil, "", err
 	}
@@ -127,6 +212,18 @@ func (c *Client) GetImage(ctx context.Context, path string, cfg *RequestConfig)
 
 func (c *Client) BaseURL() string { return c.baseURL }
 
+// Transport returns the round tripper used for requests, without the
+// limiter set by WithLimiter.
+func (c *Client) Transport() http.RoundTripper {
+	if t, ok := c.http.Transport.(*limitTransport); ok {
+		return t.base
+	}
+	if c.http.Transport == nil {
+		return http.DefaultTransport
+	}
+	return c.http.Transport
+}
+
 func (c *Client) buildURL(path string, cfg *RequestConfig) string {
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
 		if cfg != nil && len(cfg.Params) > 0 {
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnexpectedContentType = errors.New("unexpected content type")
+	ErrUnexpectedRedirect    = errors.New("unexpected redirect")
+)
+
+// snippetLen caps the body kept in an HTTPError.
+const snippetLen = 256
+
+// HTTPError reports a response whose status the request did not expect.
+type HTTPError struct {
+	StatusCode int
+	URL        string
+	// Body is the start of the response body, for diagnostics.
+	Body string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http %d %s from %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// check enforces the expectations of cfg on resp, closing its body when
+// they are not met.
+func check(resp *Response, cfg *RequestConfig) error {
+	var expectStatus []int
+	var expectType string
+	var expectPaths []string
+	if cfg != nil {
+		expectStatus, expectType, expectPaths = cfg.ExpectStatus, cfg.ExpectMediaType, cfg.ExpectPaths
+	}
+
+	// Error pages are often reached through a redirect and served as a
+	// plain 200, so the status and media type alone do not catch them.
+	if len(expectPaths) > 0 && !slices.ContainsFunc(expectPaths, func(p string) bool {
+		return strings.EqualFold(p, resp.Request.URL.Path)
+	}) {
+		resp.Response.Body.Close()
+		return fmt.Errorf("%w: %s from %s, want %s",
+			ErrUnexpectedRedirect, resp.Request.URL, firstRequest(resp.Response).URL, strings.Join(expectPaths, " or "))
+	}
+
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if len(expectStatus) > 0 {
+		ok = slices.Contains(expectStatus, resp.StatusCode)
+	}
+	if !ok {
+		body, _ := resp.Body()
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			URL:        resp.Request.URL.String(),
+			Body:       snippet(body),
+		}
+	}
+
+	if expectType != "" {
+		contentType := resp.Header.Get("Content-Type")
+		if !matchMediaType(contentType, expectType) {
+			resp.Response.Body.Close()
+			return fmt.Errorf("%w: got %q from %s, want %s",
+				ErrUnexpectedContentType, contentType, resp.Request.URL, expectType)
+		}
+	}
+	return nil
+}
+
+// firstRequest returns the request that started the redirect chain resp
+// ends.
+func firstRequest(resp *http.Response) *http.Request {
+	req := resp.Request
+	for req.Response != nil {
+		req = req.Response.Request
+	}
+	return req
+}
+
+// matchMediaType reports whether contentType has the media type want,
+// which may end in "/*" to match any subtype.
+func matchMediaType(contentType, want string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(want, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return mediaType == want
+}
+
+func snippet(body []byte) string {
+	if len(body) <= snippetLen {
+		return string(body)
+	}
+	body = body[:snippetLen]
+	for len(body) > 0 && !utf8.Valid(body) {
+		body = body[:len(body)-1]
+	}
+	return string(body) + "…"
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpectations(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		cfg         *RequestConfig
+		wantStatus  int
+		wantErr     error
+	}{
+		{name: "ok", status: 200, contentType: "text/html"},
+		{name: "server_error", status: 500, contentType: "text/html", body: "Ocorreu um erro", wantStatus: 500},
+		{name: "not_found", status: 404, contentType: "text/html", wantStatus: 404},
+		{name: "expected_status", status: 404, contentType: "text/html", cfg: &RequestConfig{ExpectStatus: []int{200, 404}}},
+		{name: "unexpected_status", status: 204, contentType: "text/html", cfg: &RequestConfig{ExpectStatus: []int{200}}, wantStatus: 204},
+		{name: "media_type", status: 200, contentType: "text/html; charset=utf-8", cfg: &RequestConfig{ExpectMediaType: "text/html"}},
+		{name: "media_type_wildcard", status: 200, contentType: "image/png", cfg: &RequestConfig{ExpectMediaType: "image/*"}},
+		{name: "html_for_image", status: 200, contentType: "text/html", cfg: &RequestConfig{ExpectMediaType: "image/*"}, wantErr: ErrUnexpectedContentType},
+		{name: "missing_media_type", status: 200, cfg: &RequestConfig{ExpectMediaType: "text/html"}, wantErr: ErrUnexpectedContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c, err := New(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.Get(context.Background(), "/page", tt.cfg)
+
+			var httpErr *HTTPError
+			if errors.As(err, &httpErr) {
+				if httpErr.StatusCode != tt.wantStatus {
+					t.Errorf("StatusCode = %d, want %d", httpErr.StatusCode, tt.wantStatus)
+				}
+				if httpErr.URL != srv.URL+"/page" || httpErr.Body != tt.body {
+					t.Errorf("HTTPError = %+v", httpErr)
+				}
+			} else if tt.wantStatus != 0 {
+				t.Errorf("Get() error = %v, want *HTTPError", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantStatus == 0 && tt.wantErr == nil && err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestExpectPathsRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/erro.aspx", http.StatusFound)
+	})
+	mux.HandleFunc("/next", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/Page", http.StatusFound)
+	})
+	mux.HandleFunc("/Page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	})
+	mux.HandleFunc("/erro.aspx", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("Ocorreu um erro"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &RequestConfig{ExpectMediaType: "text/html", ExpectPaths: []string{"/page"}}
+
+	_, err = c.Get(context.Background(), "/page", cfg)
+	if !errors.Is(err, ErrUnexpectedRedirect) {
+		t.Fatalf("Get() error = %v, want %v", err, ErrUnexpectedRedirect)
+	}
+	if !strings.Contains(err.Error(), "/erro.aspx") {
+		t.Errorf("Get() error = %v, want the error page's URL", err)
+	}
+
+	// Paths compare case-insensitively, as ASP.NET serves them.
+	if _, err := c.Get(context.Background(), "/next", cfg); err != nil {
+		t.Errorf("Get() of a redirect to an expected path error = %v", err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("á", snippetLen)
+	got := snippet([]byte(long))
+	if want := strings.Repeat("á", snippetLen/2) + "…"; got != want {
+		t.Errorf("snippet() = %q, want %q", got, want)
+	}
+	if got := snippet([]byte("short")); got != "short" {
+		t.Errorf("snippet() = %q, want %q", got, "short")
+	}
+}
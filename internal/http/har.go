@@ -0,0 +1,341 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const redacted = "REDACTED"
+
+// HARWriter captures a client's traffic as an HTTP Archive (HAR 1.2) log,
+// for inspecting a scrape session in browser developer tools.
+type HARWriter struct {
+	mu      sync.Mutex
+	entries []harEntry
+	redact  []string
+}
+
+// NewHARWriter returns an empty writer that hides the given values, such
+// as an access key, wherever they appear in URLs, headers, cookies, form
+// payloads and text response bodies.
+func NewHARWriter(redact ...string) *HARWriter {
+	h := &HARWriter{}
+	h.Redact(redact...)
+	return h
+}
+
+// Redact adds values to hide, e.g. a captcha answer once it is known. It
+// applies to requests already captured too.
+func (h *HARWriter) Redact(values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			h.redact = append(h.redact, v)
+		}
+	}
+}
+
+// Len returns the number of requests captured.
+func (h *HARWriter) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Transport returns a round tripper that sends requests through base and
+// captures each exchange.
+func (h *HARWriter) Transport(base http.RoundTripper) http.RoundTripper {
+	return &harTransport{base: base, har: h}
+}
+
+// WithHAR captures the client's traffic into h.
+func WithHAR(h *HARWriter) Option {
+	return WrapTransport(h.Transport)
+}
+
+// MarshalJSON encodes the HAR document.
+func (h *HARWriter) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r := strings.NewReplacer(h.replacements()...)
+	entries := make([]harEntry, len(h.entries))
+	for i, e := range h.entries {
+		entries[i] = e
+		entries[i].Request = e.Request.redact(r)
+		entries[i].Response = e.Response.redact(r)
+	}
+	return json.Marshal(harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "brisa", Version: "1"},
+		Entries: entries,
+	}})
+}
+
+// WriteTo writes the HAR document to w.
+func (h *HARWriter) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func (h *HARWriter) replacements() []string {
+	var pairs []string
+	for _, v := range h.redact {
+		pairs = append(pairs, v, redacted)
+	}
+	return pairs
+}
+
+type harTransport struct {
+	base http.RoundTripper
+	har  *HARWriter
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded, req, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	receive := time.Since(start) - wait
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            millis(wait + receive),
+		Request:         newHARRequest(req, recorded),
+		Response:        newHARResponse(resp, body),
+		Cache:           struct{}{},
+		Timings:         harTimings{Send: 0, Wait: millis(wait), Receive: millis(receive)},
+	}
+
+	t.har.mu.Lock()
+	t.har.entries = append(t.har.entries, entry)
+	t.har.mu.Unlock()
+	return resp, nil
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []harNameValue `json:"params,omitempty"`
+	Text     string         `json:"text"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+func newHARRequest(req *http.Request, recorded RecordedRequest) harRequest {
+	r := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(req.Header),
+		QueryString: harValues(req.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	for _, c := range req.Cookies() {
+		r.Cookies = append(r.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	if recorded.Form != nil || recorded.Body != nil {
+		text := string(recorded.Body)
+		if recorded.Form != nil {
+			text = recorded.Form.Encode()
+		}
+		r.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Params:   harValues(recorded.Form),
+			Text:     text,
+		}
+		r.BodySize = len(text)
+	}
+	return r
+}
+
+// hideValues returns a copy of nvs with the values rep replaces hidden.
+func hideValues(rep *strings.Replacer, nvs []harNameValue) []harNameValue {
+	out := make([]harNameValue, len(nvs))
+	for i, nv := range nvs {
+		out[i] = harNameValue{Name: nv.Name, Value: rep.Replace(nv.Value)}
+	}
+	return out
+}
+
+// redact returns a copy of r with the values rep replaces hidden.
+func (r harRequest) redact(rep *strings.Replacer) harRequest {
+	hide := func(nvs []harNameValue) []harNameValue { return hideValues(rep, nvs) }
+	r.URL = rep.Replace(r.URL)
+	r.Cookies = hide(r.Cookies)
+	r.Headers = hide(r.Headers)
+	r.QueryString = hide(r.QueryString)
+	if r.PostData != nil {
+		pd := *r.PostData
+		pd.Params = hide(pd.Params)
+		// Form text is URL-encoded; rebuild it from the redacted params.
+		if len(pd.Params) > 0 {
+			v := url.Values{}
+			for _, p := range pd.Params {
+				v.Add(p.Name, p.Value)
+			}
+			pd.Text = v.Encode()
+		} else {
+			pd.Text = rep.Replace(pd.Text)
+		}
+		r.PostData = &pd
+	}
+	return r
+}
+
+// redact returns a copy of r with the values rep replaces hidden. Binary
+// content, kept as base64, is left as is.
+func (r harResponse) redact(rep *strings.Replacer) harResponse {
+	r.Cookies = hideValues(rep, r.Cookies)
+	r.Headers = hideValues(rep, r.Headers)
+	r.RedirectURL = rep.Replace(r.RedirectURL)
+	if r.Content.Encoding == "" {
+		r.Content.Text = rep.Replace(r.Content.Text)
+	}
+	return r
+}
+
+func newHARResponse(resp *http.Response, body []byte) harResponse {
+	r := harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+		Content: harContent{
+			Size:     len(body),
+			MimeType: resp.Header.Get("Content-Type"),
+		},
+	}
+	for _, c := range resp.Cookies() {
+		r.Cookies = append(r.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Content.MimeType)
+	if isText(r.Content.MimeType) || strings.HasPrefix(mediaType, "text/") {
+		if decoded, _, err := DecodeHTML(body, r.Content.MimeType); err == nil && utf8.Valid(decoded) {
+			r.Content.Text = string(decoded)
+			return r
+		}
+	}
+	r.Content.Text = base64.StdEncoding.EncodeToString(body)
+	r.Content.Encoding = "base64"
+	return r
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	return harValues(url.Values(h))
+}
+
+// harValues flattens v sorted by name, keeping the order of each name's
+// values.
+func harValues(v url.Values) []harNameValue {
+	out := []harNameValue{}
+	for _, name := range slices.Sorted(maps.Keys(v)) {
+		for _, val := range v[name] {
+			out = append(out, harNameValue{Name: name, Value: val})
+		}
+	}
+	return out
+}
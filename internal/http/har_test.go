@@ -0,0 +1,100 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHARWriter(t *testing.T) {
+	const key = "29250306057223031484650140003829591141073162"
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /page", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("S\xe9rie"))
+	})
+	mux.HandleFunc("GET /captcha", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	})
+	mux.HandleFunc("POST /page", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "last_key", Value: key})
+		w.Header().Set("X-Key", key)
+		w.Write([]byte(`<span id="lbl_chave_acesso">` + key + `</span>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	har := NewHARWriter(key)
+	c, err := New(srv.URL, WithHAR(har))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := c.Get(ctx, "/page", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "/captcha", &RequestConfig{Params: url.Values{"t": {"1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PostForm(ctx, "/page", url.Values{"key": {key}, "captcha": {"K7M2Q"}, "btn": {"Consultar"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	har.Redact("K7M2Q")
+
+	var buf bytes.Buffer
+	if _, err := har.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, key) || strings.Contains(out, "K7M2Q") {
+		t.Errorf("HAR leaks redacted values:\n%s", out)
+	}
+
+	var doc harDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("decode HAR: %v", err)
+	}
+	if doc.Log.Version != "1.2" || len(doc.Log.Entries) != 3 || har.Len() != 3 {
+		t.Fatalf("log = version %q with %d entries, want 1.2 with 3", doc.Log.Version, len(doc.Log.Entries))
+	}
+
+	page, captcha, post := doc.Log.Entries[0], doc.Log.Entries[1], doc.Log.Entries[2]
+	if page.Response.Content.Text != "Série" || page.Response.Content.Encoding != "" {
+		t.Errorf("page content = %+v, want decoded text", page.Response.Content)
+	}
+	if len(page.Response.Cookies) != 1 || page.Response.Cookies[0].Value != "s1" {
+		t.Errorf("page cookies = %v", page.Response.Cookies)
+	}
+	if captcha.Response.Content.Encoding != "base64" || captcha.Response.Content.Text != "iVBORw==" {
+		t.Errorf("captcha content = %+v, want base64", captcha.Response.Content)
+	}
+	if got := captcha.Request.QueryString; !slices.Equal(got, []harNameValue{{"t", "1"}}) {
+		t.Errorf("captcha query = %v", captcha.Request.QueryString)
+	}
+	if post.Request.PostData == nil {
+		t.Fatal("POST has no postData")
+	}
+	want := []harNameValue{{"btn", "Consultar"}, {"captcha", redacted}, {"key", redacted}}
+	if got := post.Request.PostData.Params; !slices.Equal(got, want) {
+		t.Errorf("postData params = %v, want %v", got, want)
+	}
+	if got := post.Request.PostData.Text; got != "btn=Consultar&captcha=REDACTED&key=REDACTED" {
+		t.Errorf("postData text = %q", got)
+	}
+	if got := post.Response.Content.Text; got != `<span id="lbl_chave_acesso">REDACTED</span>` {
+		t.Errorf("POST response text = %q, want the key redacted", got)
+	}
+	if len(post.Request.Cookies) != 1 || post.Request.Cookies[0].Name != "session" {
+		t.Errorf("POST cookies = %v", post.Request.Cookies)
+	}
+	if post.Time < post.Timings.Wait {
+		t.Errorf("time %v < wait %v", post.Time, post.Timings.Wait)
+	}
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCooldown is how long a host is left alone after a 429 or 503
+	// without a Retry-After header.
+	defaultCooldown = time.Second
+	// maxRetryAfter is the longest Retry-After a request is retried after;
+	// longer waits return the response to the caller.
+	maxRetryAfter = 30 * time.Second
+)
+
+// LimitConfig paces the requests sent to each host.
+type LimitConfig struct {
+	// Rate is the sustained number of requests per second. Zero or less
+	// disables pacing.
+	Rate float64
+	// Burst is how many requests may be sent at once after an idle period.
+	// Values below 1 mean 1.
+	Burst int
+	// MaxConcurrent caps the requests in flight. Zero means no cap.
+	MaxConcurrent int
+}
+
+// Limiter is a token bucket and concurrency cap per host. It is safe for
+// concurrent use and meant to be shared by every Client that talks to the
+// same hosts.
+type Limiter struct {
+	mu      sync.Mutex
+	cfg     LimitConfig
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// until holds requests back after a 429 or 503.
+	until time.Time
+	sem   chan struct{}
+}
+
+// NewLimiter returns a limiter applying cfg to each host separately.
+func NewLimiter(cfg LimitConfig) *Limiter {
+	return &Limiter{cfg: cfg, buckets: map[string]*bucket{}}
+}
+
+// SetConfig replaces the limits. Requests already admitted are not
+// affected.
+func (l *Limiter) SetConfig(cfg LimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	clear(l.buckets)
+}
+
+// Wait blocks until a request to host may be sent, or ctx is done. The
+// returned function must be called once the request has completed.
+func (l *Limiter) Wait(ctx context.Context, host string) (release func(), err error) {
+	b, cfg := l.bucket(host)
+
+	release = func() {}
+	if b.sem != nil {
+		select {
+		case b.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-b.sem }) }
+	}
+
+	for {
+		d := l.reserve(b, cfg, time.Now())
+		if d <= 0 {
+			return release, nil
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			release()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Backoff keeps requests to host waiting for d, as asked by a Retry-After
+// header.
+func (l *Limiter) Backoff(host string, d time.Duration) {
+	b, _ := l.bucket(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.until) {
+		b.until = until
+	}
+}
+
+func (l *Limiter) bucket(host string) (*bucket, LimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{tokens: float64(max(l.cfg.Burst, 1)), last: time.Now()}
+		if l.cfg.MaxConcurrent > 0 {
+			b.sem = make(chan struct{}, l.cfg.MaxConcurrent)
+		}
+		l.buckets[host] = b
+	}
+	return b, l.cfg
+}
+
+// reserve takes a token from b and returns zero, or returns how long to
+// wait before trying again.
+func (l *Limiter) reserve(b *bucket, cfg LimitConfig, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Before(b.until) {
+		return b.until.Sub(now)
+	}
+	if cfg.Rate <= 0 {
+		return 0
+	}
+
+	burst := float64(max(cfg.Burst, 1))
+	b.tokens = min(burst, b.tokens+now.Sub(b.last).Seconds()*cfg.Rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / cfg.Rate * float64(time.Second))
+}
+
+// WithLimiter paces requests, redirects included, through l. Responses
+// with status 429 or 503 hold the host back for their Retry-After and are
+// retried once when that wait is short.
+func WithLimiter(l *Limiter) Option {
+	return func(c *Client) { c.limiter = l }
+}
+
+// limitTransport applies a Limiter around a base round tripper.
+type limitTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		release, err := t.limiter.Wait(req.Context(), req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			return resp, nil
+		}
+		wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = defaultCooldown
+		}
+		t.limiter.Backoff(req.URL.Host, wait)
+
+		if retried || wait > maxRetryAfter || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// releaseBody frees the limiter slot of a request once its body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// parseRetryAfter reads a Retry-After value given in seconds or as an HTTP
+// date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	return max(t.Sub(now), 0), true
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterRate(t *testing.T) {
+	l := NewLimiter(LimitConfig{Rate: 50, Burst: 2})
+	start := time.Now()
+	for range 4 {
+		release, err := l.Wait(context.Background(), "example.com")
+		if err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+		release()
+	}
+	// Two requests pass on the burst, the other two wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("4 requests took %v, want at least 40ms", elapsed)
+	}
+
+	// Other hosts have their own bucket.
+	start = time.Now()
+	release, err := l.Wait(context.Background(), "other.example.com")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("first request to another host waited %v", elapsed)
+	}
+}
+
+func TestLimiterContext(t *testing.T) {
+	l := NewLimiter(LimitConfig{MaxConcurrent: 1})
+	release, err := l.Wait(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx, "example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() on a full host error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	l.Backoff("cooling.example.com", time.Hour)
+	if _, err := l.Wait(ctx, "cooling.example.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() during backoff error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWithLimiterMaxConcurrent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithLimiter(NewLimiter(LimitConfig{MaxConcurrent: 2})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Go(func() {
+			resp, err := c.Get(context.Background(), "/", nil)
+			if err != nil {
+				t.Errorf("Get() error = %v", err)
+				return
+			}
+			resp.Body()
+		})
+	}
+	wg.Wait()
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", got)
+	}
+}
+
+func TestWithLimiterRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		wantStatus int
+		wantCalls  int32
+	}{
+		{name: "too_many_requests", status: http.StatusTooManyRequests, retryAfter: "0", wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "unavailable", status: http.StatusServiceUnavailable, retryAfter: "0", wantStatus: http.StatusOK, wantCalls: 2},
+		{name: "long_wait", status: http.StatusTooManyRequests, retryAfter: "3600", wantStatus: http.StatusTooManyRequests, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				if r.PostForm.Get("k") != "v" {
+					t.Errorf("call %d form = %v", calls.Load()+1, r.PostForm)
+				}
+				if calls.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer srv.Close()
+
+			c, err := New(srv.URL, WithLimiter(NewLimiter(LimitConfig{})))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := c.PostForm(context.Background(), "/", url.Values{"k": {"v"}}, &RequestConfig{
+				ExpectStatus: []int{http.StatusOK, tt.status},
+			})
+			if err != nil {
+				t.Fatalf("PostForm() error = %v", err)
+			}
+			resp.Body()
+			if resp.StatusCode != tt.wantStatus || calls.Load() != tt.wantCalls {
+				t.Errorf("status = %d after %d calls, want %d after %d", resp.StatusCode, calls.Load(), tt.wantStatus, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{in: "", wantOK: false},
+		{in: "120", want: 2 * time.Minute, wantOK: true},
+		{in: "-5", want: 0, wantOK: true},
+		{in: "Mon, 03 Nov 2025 12:00:30 GMT", want: 30 * time.Second, wantOK: true},
+		{in: "Mon, 03 Nov 2025 11:00:00 GMT", want: 0, wantOK: true},
+		{in: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
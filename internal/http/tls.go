@@ -0,0 +1,60 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	_ "embed"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// icpBrasilPEM holds the ICP-Brasil root CAs that sign government service
+// certificates, such as the SEFAZ portals'.
+//
+//go:embed certs/icp-brasil.pem
+var icpBrasilPEM []byte
+
+// ErrNoICPBrasilCerts reports an embedded bundle without certificates.
+var ErrNoICPBrasilCerts = errors.New("no certificates in the ICP-Brasil bundle")
+
+var icpBrasilPool = sync.OnceValues(func() (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, err
+	}
+	if !pool.AppendCertsFromPEM(icpBrasilPEM) {
+		return nil, ErrNoICPBrasilCerts
+	}
+	return pool, nil
+})
+
+// ICPBrasilPool returns the system roots extended with the embedded
+// ICP-Brasil bundle. The pool is shared and must not be modified.
+func ICPBrasilPool() (*x509.CertPool, error) {
+	return icpBrasilPool()
+}
+
+// WithRootCAs verifies server certificates against pool instead of the
+// system roots.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *Client) {
+		c.http.Transport = newTransport(&tls.Config{RootCAs: pool})
+	}
+}
+
+// WithInsecureSkipVerify disables server certificate verification. It is
+// meant for debugging against hosts with broken certificate chains only.
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) {
+		c.http.Transport = newTransport(&tls.Config{InsecureSkipVerify: true})
+	}
+}
+
+// newTransport returns a copy of http.DefaultTransport, keeping its proxy
+// and timeout settings, that uses cfg for TLS.
+func newTransport(cfg *tls.Config) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = cfg
+	return t
+}
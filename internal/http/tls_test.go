@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newCA returns a CA certificate and a server certificate for 127.0.0.1
+// signed by it.
+func newCA(t *testing.T) (*x509.Certificate, tls.Certificate) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Raiz"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, tls.Certificate{Certificate: [][]byte{leafDER}, PrivateKey: leafKey}
+}
+
+// icpBrasilRoots lists the SHA-256 fingerprints of the roots the embedded
+// bundle must hold, so that it cannot shrink unnoticed.
+var icpBrasilRoots = map[string]string{
+	"caa53fc6091c6951887c976e378f6ef89aa6377c55d97b6475422b71ed7e9b17": "Autoridade Certificadora Raiz Brasileira v5",
+}
+
+func TestICPBrasilBundle(t *testing.T) {
+	found := map[string]bool{}
+	rest := icpBrasilPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("ParseCertificate() error = %v", err)
+		}
+		if !cert.IsCA {
+			t.Errorf("%s is not a CA", cert.Subject)
+		}
+		sum := sha256.Sum256(cert.Raw)
+		found[hex.EncodeToString(sum[:])] = true
+	}
+	for fingerprint, name := range icpBrasilRoots {
+		if !found[fingerprint] {
+			t.Errorf("the embedded bundle lacks %s (%s)", name, fingerprint)
+		}
+	}
+}
+
+func TestTLSVerification(t *testing.T) {
+	ca, cert := newCA(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(ca)
+	icpBrasil, err := ICPBrasilPool()
+	if err != nil {
+		t.Fatalf("ICPBrasilPool() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr bool
+	}{
+		{name: "custom_ca", opt: WithRootCAs(trusted)},
+		// The test CA chains to no ICP-Brasil root.
+		{name: "icp_brasil", opt: WithRootCAs(icpBrasil), wantErr: true},
+		{name: "empty_pool", opt: WithRootCAs(x509.NewCertPool()), wantErr: true},
+		{name: "insecure", opt: WithInsecureSkipVerify()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(srv.URL, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.Get(context.Background(), "/", nil)
+			var certErr *tls.CertificateVerificationError
+			if got := errors.As(err, &certErr); got != tt.wantErr {
+				t.Errorf("Get() error = %v, want certificate error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+		})
+	}
+}
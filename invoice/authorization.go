@@ -0,0 +1,62 @@
+package invoice
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseEnvironment maps a tpAmb code or the portal's wording, such as
+// "produção", to an Environment. Unknown values yield zero.
+func ParseEnvironment(s string) Environment {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "1" || strings.HasPrefix(s, "produ"):
+		return EnvironmentProduction
+	case s == "2" || strings.HasPrefix(s, "homolog"):
+		return EnvironmentHomologation
+	}
+	return 0
+}
+
+// Emission types, the tpEmis codes that matter for NFC-e.
+const (
+	EmissionNormal = 1
+	// EmissionOffline is the NFC-e offline contingency: the invoice was
+	// issued without SEFAZ and authorized once transmitted later.
+	EmissionOffline = 9
+)
+
+// ParseEmissionType returns the tpEmis code of values such as "1 - Normal",
+// or zero if s has none.
+func ParseEmissionType(s string) int {
+	n, _ := strconv.Atoi(leadingCode(s))
+	return n
+}
+
+// cStat codes of the invoice's current status.
+const (
+	CStatAuthorized = 100
+	CStatCancelled  = 101
+	// CStatCancelledLate is a cancellation accepted after the deadline.
+	CStatCancelledLate = 151
+	CStatDenied        = 110
+)
+
+// Authorization is SEFAZ's acknowledgement that an invoice is valid, the
+// protNFe group. The protocol number proves the invoice's authenticity.
+type Authorization struct {
+	Protocol     string    `json:"protocol"`
+	AuthorizedAt time.Time `json:"authorized_at"`
+	// StatusCode and StatusMessage are cStat and xMotivo.
+	StatusCode    int         `json:"status_code,omitempty"`
+	StatusMessage string      `json:"status_message,omitempty"`
+	Environment   Environment `json:"environment,omitempty"`
+	EmissionType  int         `json:"emission_type,omitempty"`
+}
+
+// Contingency reports whether the invoice was issued in contingency and
+// authorized after the sale.
+func (a *Authorization) Contingency() bool {
+	return a.EmissionType != 0 && a.EmissionType != EmissionNormal
+}
@@ -1,34 +1,149 @@
 package invoice
 
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccessKeyLength is the number of digits in an NF-e/NFC-e access key.
+const AccessKeyLength = 44
+
+var (
+	ErrAccessKeyLength     = errors.New("access key must have 44 digits")
+	ErrAccessKeyNonDigit   = errors.New("access key must contain only digits")
+	ErrAccessKeyRepeated   = errors.New("access key cannot repeat a single digit")
+	ErrAccessKeyCheckDigit = errors.New("access key check digit mismatch")
+)
+
+// Model identifies the fiscal document model encoded in the access key.
+type Model int
+
+const (
+	ModelNFe  Model = 55
+	ModelNFCe Model = 65
+)
+
+// AccessKey holds the fields encoded in a 44-digit access key.
+//
+// Layout: cUF(2) AAMM(4) CNPJ(14) mod(2) serie(3) nNF(9) tpEmis(1) cNF(8) cDV(1).
+type AccessKey struct {
+	UF           int
+	Year         int
+	Month        time.Month
+	CNPJ         string
+	Model        Model
+	Series       int
+	Number       int
+	EmissionType int
+	Code         string
+	CheckDigit   int
+
+	raw string
+}
+
+// ParseAccessKey decodes and validates an access key.
+// Whitespace is ignored so the grouped form printed on receipts is accepted.
+func ParseAccessKey(s string) (AccessKey, error) {
+	key := strings.Join(strings.Fields(s), "")
+
+	if len(key) != AccessKeyLength {
+		return AccessKey{}, fmt.Errorf("%w: got %d", ErrAccessKeyLength, len(key))
+	}
+	for i, r := range key {
+		if r < '0' || r > '9' {
+			return AccessKey{}, fmt.Errorf("%w: %q at position %d", ErrAccessKeyNonDigit, r, i+1)
+		}
+	}
+	if strings.Count(key, key[:1]) == len(key) {
+		return AccessKey{}, ErrAccessKeyRepeated
+	}
+
+	dv := atoi(key[AccessKeyLength-1:])
+	if want := checkDigit(key[:AccessKeyLength-1]); dv != want {
+		return AccessKey{}, fmt.Errorf("%w: got %d, want %d", ErrAccessKeyCheckDigit, dv, want)
+	}
+
+	return AccessKey{
+		UF:           atoi(key[0:2]),
+		Year:         2000 + atoi(key[2:4]),
+		Month:        time.Month(atoi(key[4:6])),
+		CNPJ:         key[6:20],
+		Model:        Model(atoi(key[20:22])),
+		Series:       atoi(key[22:25]),
+		Number:       atoi(key[25:34]),
+		EmissionType: atoi(key[34:35]),
+		Code:         key[35:43],
+		CheckDigit:   dv,
+		raw:          key,
+	}, nil
+}
+
 // IsValidAccessKey checks if the access key is valid.
 // It checks length, numeric content, and the check digit (DV).
 func IsValidAccessKey(key string) bool {
-	if len(key) != 44 {
-		return false
-	}
+	_, err := ParseAccessKey(key)
+	return err == nil
+}
 
-	for _, r := range key {
-		if r < '0' || r > '9' {
-			return false
+// IsZero reports whether k holds no key.
+func (k AccessKey) IsZero() bool { return k.raw == "" }
+
+// String returns the 44 digits of the key.
+func (k AccessKey) String() string { return k.raw }
+
+// Formatted returns the key in 4-digit groups, as printed on the DANFE.
+func (k AccessKey) Formatted() string {
+	var b strings.Builder
+	for i := 0; i < len(k.raw); i += 4 {
+		if i > 0 {
+			b.WriteByte(' ')
 		}
+		b.WriteString(k.raw[i:min(i+4, len(k.raw))])
 	}
+	return b.String()
+}
 
-	allSame := true
-	for i := 1; i < len(key); i++ {
-		if key[i] != key[0] {
-			allSame = false
-			break
-		}
+// MarshalText encodes the key as its 44 digits.
+func (k AccessKey) MarshalText() ([]byte, error) {
+	return []byte(k.raw), nil
+}
+
+// UnmarshalText parses and validates the key. Empty input yields the zero key.
+func (k *AccessKey) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*k = AccessKey{}
+		return nil
 	}
-	if allSame {
-		return false
+	parsed, err := ParseAccessKey(string(data))
+	if err != nil {
+		return err
 	}
+	*k = parsed
+	return nil
+}
 
+// ComputeCheckDigit returns the mod-11 check digit (cDV) for the first 43 digits of a key.
+func ComputeCheckDigit(first43 string) (int, error) {
+	if len(first43) != AccessKeyLength-1 {
+		return 0, fmt.Errorf("%w: got %d, want 43 digits before the check digit", ErrAccessKeyLength, len(first43))
+	}
+	for i, r := range first43 {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%w: %q at position %d", ErrAccessKeyNonDigit, r, i+1)
+		}
+	}
+	return checkDigit(first43), nil
+}
+
+func checkDigit(first43 string) int {
 	total := 0
 	multiplier := 2
 
-	for i := 42; i >= 0; i-- {
-		digit := int(key[i] - '0')
+	for i := len(first43) - 1; i >= 0; i-- {
+		digit := int(first43[i] - '0')
 		total += digit * multiplier
 		multiplier++
 		if multiplier > 9 {
@@ -37,11 +152,13 @@ func IsValidAccessKey(key string) bool {
 	}
 
 	remainder := total % 11
-	dv := 11 - remainder
 	if remainder == 0 || remainder == 1 {
-		dv = 0
+		return 0
 	}
+	return 11 - remainder
+}
 
-	expectedDV := int(key[43] - '0')
-	return dv == expectedDV
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
 }
@@ -0,0 +1,107 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrAccessKeyField = errors.New("invalid access key field")
+
+// AccessKeyBuilder assembles a valid access key from its fields and
+// computes the check digit. It is meant for fixtures and fake portals.
+type AccessKeyBuilder struct {
+	uf           int
+	year         int
+	month        time.Month
+	cnpj         string
+	model        Model
+	series       int
+	number       int
+	emissionType int
+	code         string
+}
+
+// NewAccessKeyBuilder returns a builder for a normal-emission NFC-e key.
+func NewAccessKeyBuilder() *AccessKeyBuilder {
+	return &AccessKeyBuilder{model: ModelNFCe, emissionType: 1}
+}
+
+func (b *AccessKeyBuilder) UF(code int) *AccessKeyBuilder {
+	b.uf = code
+	return b
+}
+
+func (b *AccessKeyBuilder) Period(year int, month time.Month) *AccessKeyBuilder {
+	b.year = year
+	b.month = month
+	return b
+}
+
+func (b *AccessKeyBuilder) CNPJ(cnpj string) *AccessKeyBuilder {
+	b.cnpj = cnpj
+	return b
+}
+
+func (b *AccessKeyBuilder) Model(m Model) *AccessKeyBuilder {
+	b.model = m
+	return b
+}
+
+func (b *AccessKeyBuilder) Series(n int) *AccessKeyBuilder {
+	b.series = n
+	return b
+}
+
+func (b *AccessKeyBuilder) Number(n int) *AccessKeyBuilder {
+	b.number = n
+	return b
+}
+
+func (b *AccessKeyBuilder) EmissionType(n int) *AccessKeyBuilder {
+	b.emissionType = n
+	return b
+}
+
+func (b *AccessKeyBuilder) Code(cnf string) *AccessKeyBuilder {
+	b.code = cnf
+	return b
+}
+
+// Build validates the fields and returns the key with its check digit.
+func (b *AccessKeyBuilder) Build() (AccessKey, error) {
+	switch {
+	case b.uf < 10 || b.uf > 99:
+		return AccessKey{}, fmt.Errorf("%w: uf %d", ErrAccessKeyField, b.uf)
+	case b.year < 2000 || b.year > 2099:
+		return AccessKey{}, fmt.Errorf("%w: year %d", ErrAccessKeyField, b.year)
+	case b.month < time.January || b.month > time.December:
+		return AccessKey{}, fmt.Errorf("%w: month %d", ErrAccessKeyField, b.month)
+	case len(b.cnpj) != 14 || !isDigits(b.cnpj):
+		return AccessKey{}, fmt.Errorf("%w: cnpj %q", ErrAccessKeyField, b.cnpj)
+	case b.model < 10 || b.model > 99:
+		return AccessKey{}, fmt.Errorf("%w: model %d", ErrAccessKeyField, b.model)
+	case b.series < 0 || b.series > 999:
+		return AccessKey{}, fmt.Errorf("%w: series %d", ErrAccessKeyField, b.series)
+	case b.number < 1 || b.number > 999999999:
+		return AccessKey{}, fmt.Errorf("%w: number %d", ErrAccessKeyField, b.number)
+	case b.emissionType < 1 || b.emissionType > 9:
+		return AccessKey{}, fmt.Errorf("%w: emission type %d", ErrAccessKeyField, b.emissionType)
+	case len(b.code) != 8 || !isDigits(b.code):
+		return AccessKey{}, fmt.Errorf("%w: code %q", ErrAccessKeyField, b.code)
+	}
+
+	first43 := fmt.Sprintf("%02d%02d%02d%s%02d%03d%09d%d%s",
+		b.uf, b.year%100, int(b.month), b.cnpj, int(b.model), b.series, b.number, b.emissionType, b.code)
+
+	return ParseAccessKey(fmt.Sprintf("%s%d", first43, checkDigit(first43)))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
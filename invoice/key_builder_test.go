@@ -0,0 +1,129 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// keyFields is a random but in-range set of builder inputs for quick.Check.
+type keyFields struct {
+	UF           int
+	Year         int
+	Month        time.Month
+	CNPJ         string
+	Model        Model
+	Series       int
+	Number       int
+	EmissionType int
+	Code         string
+}
+
+func (keyFields) Generate(r *rand.Rand, _ int) reflect.Value {
+	digits := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte('0' + r.Intn(10))
+		}
+		return string(b)
+	}
+	models := []Model{ModelNFe, ModelNFCe}
+	return reflect.ValueOf(keyFields{
+		UF:           11 + r.Intn(43),
+		Year:         2000 + r.Intn(100),
+		Month:        time.Month(1 + r.Intn(12)),
+		CNPJ:         digits(14),
+		Model:        models[r.Intn(len(models))],
+		Series:       r.Intn(1000),
+		Number:       1 + r.Intn(999999999),
+		EmissionType: 1 + r.Intn(9),
+		Code:         digits(8),
+	})
+}
+
+func (f keyFields) build() (AccessKey, error) {
+	return NewAccessKeyBuilder().
+		UF(f.UF).
+		Period(f.Year, f.Month).
+		CNPJ(f.CNPJ).
+		Model(f.Model).
+		Series(f.Series).
+		Number(f.Number).
+		EmissionType(f.EmissionType).
+		Code(f.Code).
+		Build()
+}
+
+func TestAccessKeyBuilderRoundTrip(t *testing.T) {
+	roundTrip := func(f keyFields) bool {
+		key, err := f.build()
+		if err != nil {
+			t.Logf("Build(%+v) error = %v", f, err)
+			return false
+		}
+		if !IsValidAccessKey(key.String()) {
+			return false
+		}
+		parsed, err := ParseAccessKey(key.String())
+		if err != nil || parsed != key {
+			return false
+		}
+		return key.UF == f.UF && key.Year == f.Year && key.Month == f.Month &&
+			key.CNPJ == f.CNPJ && key.Model == f.Model && key.Series == f.Series &&
+			key.Number == f.Number && key.EmissionType == f.EmissionType && key.Code == f.Code
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestComputeCheckDigitMatchesParser(t *testing.T) {
+	property := func(f keyFields) bool {
+		key, err := f.build()
+		if err != nil {
+			return false
+		}
+		dv, err := ComputeCheckDigit(key.String()[:43])
+		if err != nil || dv != key.CheckDigit {
+			return false
+		}
+		// Any other check digit must be rejected.
+		wrong := key.String()[:43] + fmt.Sprint((dv+1)%10)
+		_, err = ParseAccessKey(wrong)
+		return errors.Is(err, ErrAccessKeyCheckDigit)
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestComputeCheckDigit(t *testing.T) {
+	dv, err := ComputeCheckDigit(testKey[:43])
+	if err != nil {
+		t.Fatalf("ComputeCheckDigit() error = %v", err)
+	}
+	if dv != 5 {
+		t.Errorf("ComputeCheckDigit() = %d, want 5", dv)
+	}
+
+	if _, err := ComputeCheckDigit(testKey); !errors.Is(err, ErrAccessKeyLength) {
+		t.Errorf("ComputeCheckDigit(44 digits) error = %v, want %v", err, ErrAccessKeyLength)
+	}
+}
+
+func TestAccessKeyBuilderInvalidField(t *testing.T) {
+	_, err := NewAccessKeyBuilder().
+		UF(29).
+		Period(2025, time.November).
+		CNPJ("123").
+		Number(1).
+		Code("00000001").
+		Build()
+	if !errors.Is(err, ErrAccessKeyField) {
+		t.Errorf("Build() error = %v, want %v", err, ErrAccessKeyField)
+	}
+}
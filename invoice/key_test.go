@@ -0,0 +1,92 @@
+package invoice
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testKey = "29251106057223031484650080003212191080407665"
+
+func TestParseAccessKey(t *testing.T) {
+	key, err := ParseAccessKey("2925 1106 0572 2303 1484 6500 8000 3212 1910 8040 7665")
+	if err != nil {
+		t.Fatalf("ParseAccessKey() error = %v", err)
+	}
+
+	want := AccessKey{
+		UF:           29,
+		Year:         2025,
+		Month:        time.November,
+		CNPJ:         "06057223031484",
+		Model:        ModelNFCe,
+		Series:       8,
+		Number:       321219,
+		EmissionType: 1,
+		Code:         "08040766",
+		CheckDigit:   5,
+		raw:          testKey,
+	}
+	if key != want {
+		t.Errorf("ParseAccessKey() = %+v, want %+v", key, want)
+	}
+	if key.String() != testKey {
+		t.Errorf("String() = %q, want %q", key.String(), testKey)
+	}
+	if got := key.Formatted(); got != "2925 1106 0572 2303 1484 6500 8000 3212 1910 8040 7665" {
+		t.Errorf("Formatted() = %q", got)
+	}
+}
+
+func TestParseAccessKeyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{name: "short", input: testKey[:43], want: ErrAccessKeyLength},
+		{name: "non_digit", input: testKey[:10] + "X" + testKey[11:], want: ErrAccessKeyNonDigit},
+		{name: "repeated", input: "11111111111111111111111111111111111111111111", want: ErrAccessKeyRepeated},
+		{name: "check_digit", input: testKey[:43] + "4", want: ErrAccessKeyCheckDigit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAccessKey(tt.input)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ParseAccessKey(%q) error = %v, want %v", tt.input, err, tt.want)
+			}
+			if IsValidAccessKey(tt.input) {
+				t.Errorf("IsValidAccessKey(%q) = true, want false", tt.input)
+			}
+		})
+	}
+}
+
+func TestAccessKeyJSON(t *testing.T) {
+	key, err := ParseAccessKey(testKey)
+	if err != nil {
+		t.Fatalf("ParseAccessKey() error = %v", err)
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `"`+testKey+`"` {
+		t.Errorf("json.Marshal() = %s", data)
+	}
+
+	var got AccessKey
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got != key {
+		t.Errorf("json round trip = %+v, want %+v", got, key)
+	}
+
+	if err := json.Unmarshal([]byte(`"`+testKey[:43]+`0"`), &got); !errors.Is(err, ErrAccessKeyCheckDigit) {
+		t.Errorf("json.Unmarshal(bad key) error = %v, want %v", err, ErrAccessKeyCheckDigit)
+	}
+}
@@ -1,12 +1,47 @@
 package invoice
 
 type Issuer struct {
-	Name        string  `json:"name"`
-	CNPJ        string  `json:"cnpj"`
-	TradeName   string  `json:"trade_name,omitempty"`
-	StateRegID  string  `json:"state_reg_id,omitempty"`
-	MunicipalID string  `json:"municipal_id,omitempty"`
-	Address     Address `json:"address"`
+	Name        string    `json:"name"`
+	CNPJ        string    `json:"cnpj"`
+	TradeName   string    `json:"trade_name,omitempty"`
+	StateRegID  string    `json:"state_reg_id,omitempty"`
+	MunicipalID string    `json:"municipal_id,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
+	TaxRegime   TaxRegime `json:"tax_regime,omitempty"`
+	Address     Address   `json:"address"`
+}
+
+// TaxRegime is the issuer's tax regime, the CRT field of the NF-e.
+type TaxRegime string
+
+const (
+	TaxRegimeSimples       TaxRegime = "simples_nacional"
+	TaxRegimeSimplesExcess TaxRegime = "simples_nacional_excess"
+	TaxRegimeNormal        TaxRegime = "normal"
+	TaxRegimeMEI           TaxRegime = "mei"
+)
+
+var crtCodes = map[string]TaxRegime{
+	"1": TaxRegimeSimples,
+	"2": TaxRegimeSimplesExcess,
+	"3": TaxRegimeNormal,
+	"4": TaxRegimeMEI,
+}
+
+// ParseTaxRegime maps a CRT code, alone or followed by its description as
+// in "3 - Regime Normal", to a TaxRegime. Unknown codes yield "".
+func ParseTaxRegime(s string) TaxRegime {
+	return crtCodes[leadingCode(s)]
+}
+
+// Code returns the CRT code of r, or "" if r is not a known regime.
+func (r TaxRegime) Code() string {
+	for code, regime := range crtCodes {
+		if regime == r {
+			return code
+		}
+	}
+	return ""
 }
 
 type Consumer struct {
@@ -20,6 +55,7 @@ type Address struct {
 	Complement string `json:"complement,omitempty"`
 	District   string `json:"district,omitempty"`
 	City       string `json:"city,omitempty"`
+	CityCode   string `json:"city_code,omitempty"` // IBGE municipality code
 	State      string `json:"state,omitempty"`
 	ZipCode    string `json:"zip_code,omitempty"`
 }
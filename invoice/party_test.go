@@ -0,0 +1,26 @@
+package invoice
+
+import "testing"
+
+func TestParseTaxRegime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want TaxRegime
+	}{
+		{"1", TaxRegimeSimples},
+		{"2 - Simples Nacional - excesso de sublimite de receita bruta", TaxRegimeSimplesExcess},
+		{" 3 - Regime Normal", TaxRegimeNormal},
+		{"4 - Simples Nacional - Microempreendedor Individual - MEI", TaxRegimeMEI},
+		{"9", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := ParseTaxRegime(tt.in)
+		if got != tt.want {
+			t.Errorf("ParseTaxRegime(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got != "" && ParseTaxRegime(got.Code()) != got {
+			t.Errorf("%q.Code() = %q does not parse back", got, got.Code())
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package invoice
 
-import "github.com/glwbr/brisa/money"
+import (
+	"strings"
+
+	"github.com/glwbr/brisa/money"
+)
 
 type PaymentMethod string
 
@@ -11,11 +15,133 @@ const (
 	PaymentPix         PaymentMethod = "pix"
 	PaymentBankSlip    PaymentMethod = "boleto"
 	PaymentStoreCredit PaymentMethod = "store_credit"
+	PaymentFoodVoucher PaymentMethod = "food_voucher"
+	PaymentMealVoucher PaymentMethod = "meal_voucher"
+	PaymentGiftCard    PaymentMethod = "gift_card"
+	PaymentFuelVoucher PaymentMethod = "fuel_voucher"
+	PaymentNone        PaymentMethod = "none"
 	PaymentOther       PaymentMethod = "other"
 )
 
+// paymentCodes maps the SEFAZ tPag codes to payment methods. Codes not
+// listed are PaymentOther.
+var paymentCodes = map[string]PaymentMethod{
+	"01": PaymentCash,
+	"03": PaymentCreditCard,
+	"04": PaymentDebitCard,
+	"05": PaymentStoreCredit,
+	"10": PaymentFoodVoucher,
+	"11": PaymentMealVoucher,
+	"12": PaymentGiftCard,
+	"13": PaymentFuelVoucher,
+	"15": PaymentBankSlip,
+	"17": PaymentPix,
+	"20": PaymentPix, // static QR code
+	"21": PaymentStoreCredit,
+	"90": PaymentNone,
+}
+
+// ParsePaymentCode maps a tPag code, alone or followed by its description
+// as in "17 - Pagamento Instantâneo (PIX)", to a PaymentMethod.
+func ParsePaymentCode(s string) PaymentMethod {
+	if m, ok := paymentCodes[leadingCode(s)]; ok {
+		return m
+	}
+	return PaymentOther
+}
+
+// Code returns the tPag code of m.
+func (m PaymentMethod) Code() string {
+	switch m {
+	case PaymentPix:
+		return "17"
+	case PaymentStoreCredit:
+		return "05"
+	}
+	for code, method := range paymentCodes {
+		if method == m {
+			return code
+		}
+	}
+	return "99"
+}
+
 type Payment struct {
 	Method       PaymentMethod `json:"method"`
 	Amount       money.BRL     `json:"amount"`
 	Installments int           `json:"installments,omitempty"`
+	// Card is set for card payments whose details the issuer reported.
+	Card *Card `json:"card,omitempty"`
+}
+
+// Card identifies a card transaction, for matching it on card statements.
+type Card struct {
+	// Brand is the card brand name, e.g. "Mastercard"; see ParseCardBrand.
+	Brand         string `json:"brand,omitempty"`
+	AcquirerCNPJ  string `json:"acquirer_cnpj,omitempty"`
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// cardBrands maps the SEFAZ tBand codes to brand names.
+var cardBrands = map[string]string{
+	"01": "Visa",
+	"02": "Mastercard",
+	"03": "American Express",
+	"04": "Sorocred",
+	"05": "Diners Club",
+	"06": "Elo",
+	"07": "Hipercard",
+	"08": "Aura",
+	"09": "Cabal",
+	"10": "Alelo",
+	"11": "Banes Card",
+	"12": "CalCard",
+	"13": "Credz",
+	"14": "Discover",
+	"15": "GoodCard",
+	"16": "GreenCard",
+	"17": "Hiper",
+	"18": "JCB",
+	"19": "Mais",
+	"20": "MaxVan",
+	"21": "Policard",
+	"22": "RedeCompras",
+	"23": "Sodexo",
+	"24": "ValeCard",
+	"25": "Verocheque",
+	"26": "VR",
+	"27": "Ticket",
+	"99": "Outros",
+}
+
+// ParseCardBrand returns the brand name for a tBand code, alone or
+// followed by its description. Unknown codes yield the description, if
+// any.
+func ParseCardBrand(s string) string {
+	code := leadingCode(s)
+	if name, ok := cardBrands[code]; ok {
+		return name
+	}
+	_, desc, ok := strings.Cut(s, " - ")
+	if !ok {
+		return strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(desc)
+}
+
+// CardBrandCode returns the tBand code of a brand name returned by
+// ParseCardBrand, or "" if it has none.
+func CardBrandCode(name string) string {
+	for code, n := range cardBrands {
+		if strings.EqualFold(n, name) {
+			return code
+		}
+	}
+	return ""
+}
+
+// leadingCode returns the code of values shown as "code - description".
+func leadingCode(s string) string {
+	code, _, _ := strings.Cut(strings.TrimSpace(s), " ")
+	return code
 }
@@ -0,0 +1,55 @@
+package invoice
+
+import "testing"
+
+func TestParsePaymentCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want PaymentMethod
+	}{
+		{"01", PaymentCash},
+		{"03 - Cartão de Crédito", PaymentCreditCard},
+		{"04", PaymentDebitCard},
+		{"05", PaymentStoreCredit},
+		{"10 - Vale Alimentação", PaymentFoodVoucher},
+		{"11", PaymentMealVoucher},
+		{"12", PaymentGiftCard},
+		{"13", PaymentFuelVoucher},
+		{"15", PaymentBankSlip},
+		{" 17 - Pagamento Instantâneo (PIX)", PaymentPix},
+		{"20", PaymentPix},
+		{"90 - Sem pagamento", PaymentNone},
+		{"99 - Outros", PaymentOther},
+		{"02", PaymentOther},
+		{"", PaymentOther},
+	}
+	for _, tt := range tests {
+		got := ParsePaymentCode(tt.in)
+		if got != tt.want {
+			t.Errorf("ParsePaymentCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if ParsePaymentCode(got.Code()) != got {
+			t.Errorf("%q.Code() = %q does not parse back", got, got.Code())
+		}
+	}
+}
+
+func TestParseCardBrand(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"02", "Mastercard"},
+		{"06 - Elo", "Elo"},
+		{"98 - Bandeira Nova", "Bandeira Nova"},
+		{"Visa", "Visa"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ParseCardBrand(tt.in); got != tt.want {
+			t.Errorf("ParseCardBrand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := CardBrandCode("mastercard"); got != "02" {
+		t.Errorf("CardBrandCode(%q) = %q, want %q", "mastercard", got, "02")
+	}
+}
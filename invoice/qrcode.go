@@ -0,0 +1,161 @@
+package invoice
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/glwbr/brisa/money"
+)
+
+var ErrInvalidQRCode = errors.New("invalid NFC-e QR code")
+
+// Environment is the SEFAZ environment (tpAmb) a document was issued in.
+type Environment int
+
+const (
+	EnvironmentProduction   Environment = 1
+	EnvironmentHomologation Environment = 2
+)
+
+func (e Environment) String() string {
+	switch e {
+	case EnvironmentProduction:
+		return "production"
+	case EnvironmentHomologation:
+		return "homologation"
+	default:
+		return "unknown"
+	}
+}
+
+// QRCode holds the fields of an NFC-e QR code payload (the "p" parameter).
+//
+// Supported layouts:
+//
+//	v2 online:  chave|2|tpAmb|cIdToken|cHashQRCode
+//	v2 offline: chave|2|tpAmb|dia|vNF|digVal|cIdToken|cHashQRCode
+//	v3 online:  chave|3|tpAmb
+//	v3 offline: chave|3|tpAmb|dia|vNF|tpIdDest|idDest|assinatura
+type QRCode struct {
+	URL         string
+	AccessKey   AccessKey
+	Version     int
+	Environment Environment
+	Offline     bool
+
+	// Offline (contingency) fields.
+	IssueDay    int
+	Total       money.BRL
+	DigestValue string
+
+	// v2 fields.
+	TokenID string
+	Hash    string
+
+	// v3 offline fields.
+	RecipientIDType string
+	RecipientID     string
+	Signature       string
+}
+
+// ParseQRCodeURL extracts and validates the payload of a SEFAZ NFC-e QR code URL.
+func ParseQRCodeURL(raw string) (*QRCode, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQRCode, err)
+	}
+	payload, err := queryValue(u.RawQuery, "p")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQRCode, err)
+	}
+	if payload == "" {
+		return nil, fmt.Errorf("%w: missing p parameter", ErrInvalidQRCode)
+	}
+
+	fields := strings.Split(payload, "|")
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("%w: expected at least 3 fields, got %d", ErrInvalidQRCode, len(fields))
+	}
+
+	key, err := ParseAccessKey(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQRCode, err)
+	}
+
+	qr := &QRCode{URL: raw, AccessKey: key}
+
+	if qr.Version, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, fmt.Errorf("%w: version %q", ErrInvalidQRCode, fields[1])
+	}
+	switch env := Environment(atoi(fields[2])); env {
+	case EnvironmentProduction, EnvironmentHomologation:
+		qr.Environment = env
+	default:
+		return nil, fmt.Errorf("%w: environment %q", ErrInvalidQRCode, fields[2])
+	}
+
+	switch {
+	case qr.Version == 2 && len(fields) == 5:
+		qr.TokenID, qr.Hash = fields[3], fields[4]
+	case qr.Version == 2 && len(fields) == 8:
+		qr.Offline = true
+		if err := qr.parseOffline(fields[3], fields[4]); err != nil {
+			return nil, err
+		}
+		qr.DigestValue = decodeDigestValue(fields[5])
+		qr.TokenID, qr.Hash = fields[6], fields[7]
+	case qr.Version == 3 && len(fields) == 3:
+	case qr.Version == 3 && len(fields) == 8:
+		qr.Offline = true
+		if err := qr.parseOffline(fields[3], fields[4]); err != nil {
+			return nil, err
+		}
+		qr.RecipientIDType, qr.RecipientID, qr.Signature = fields[5], fields[6], fields[7]
+	default:
+		return nil, fmt.Errorf("%w: unsupported layout v%d with %d fields", ErrInvalidQRCode, qr.Version, len(fields))
+	}
+
+	return qr, nil
+}
+
+// queryValue returns the first value of name in a raw query string. Unlike
+// url.Values it keeps "+" as is: v3 signatures are base64 and printers do
+// not escape them.
+func queryValue(rawQuery, name string) (string, error) {
+	for _, pair := range strings.Split(rawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if k != name {
+			continue
+		}
+		return url.PathUnescape(v)
+	}
+	return "", nil
+}
+
+func (qr *QRCode) parseOffline(day, total string) error {
+	qr.IssueDay = atoi(day)
+	if qr.IssueDay < 1 || qr.IssueDay > 31 {
+		return fmt.Errorf("%w: issue day %q", ErrInvalidQRCode, day)
+	}
+	v, err := money.ParseDecimal(total)
+	if err != nil {
+		return fmt.Errorf("%w: total %q: %w", ErrInvalidQRCode, total, err)
+	}
+	qr.Total = v
+	return nil
+}
+
+// decodeDigestValue returns the base64 DigestValue, which v2 offline codes
+// carry hex-encoded. Values that are not valid hex are returned as is.
+func decodeDigestValue(s string) string {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return s
+	}
+	return string(b)
+}
@@ -0,0 +1,108 @@
+package invoice
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseQRCodeURL(t *testing.T) {
+	const base = "http://nfe.sefaz.ba.gov.br/servicos/nfce/modulos/geral/NFCEC_consulta_chave_acesso.aspx?p="
+
+	tests := []struct {
+		name string
+		url  string
+		want QRCode
+	}{
+		{
+			name: "v2_online",
+			url:  base + testKey + "|2|1|1|3A5B7C9D0E1F",
+			want: QRCode{Version: 2, Environment: EnvironmentProduction, TokenID: "1", Hash: "3A5B7C9D0E1F"},
+		},
+		{
+			name: "v2_offline",
+			url:  base + testKey + "|2|2|19|527.84|5334392b4a6f366c494e765461446d4b6451592b5939724f4d54493d|1|ABCDEF",
+			want: QRCode{
+				Version:     2,
+				Environment: EnvironmentHomologation,
+				Offline:     true,
+				IssueDay:    19,
+				Total:       52784,
+				DigestValue: "S49+Jo6lINvTaDmKdQY+Y9rOMTI=",
+				TokenID:     "1",
+				Hash:        "ABCDEF",
+			},
+		},
+		{
+			name: "v3_online_escaped",
+			url:  base + testKey + "%7C3%7C1",
+			want: QRCode{Version: 3, Environment: EnvironmentProduction},
+		},
+		{
+			name: "v3_offline",
+			url:  base + testKey + "|3|1|19|10.50|1|12345678909|c2lnbmF0dXJl",
+			want: QRCode{
+				Version:         3,
+				Environment:     EnvironmentProduction,
+				Offline:         true,
+				IssueDay:        19,
+				Total:           1050,
+				RecipientIDType: "1",
+				RecipientID:     "12345678909",
+				Signature:       "c2lnbmF0dXJl",
+			},
+		},
+		{
+			name: "v3_offline_base64_plus",
+			url:  base + testKey + "|3|1|19|10.50|1|12345678909|ab+/cd+e%2Bf==",
+			want: QRCode{
+				Version:         3,
+				Environment:     EnvironmentProduction,
+				Offline:         true,
+				IssueDay:        19,
+				Total:           1050,
+				RecipientIDType: "1",
+				RecipientID:     "12345678909",
+				Signature:       "ab+/cd+e+f==",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQRCodeURL(tt.url)
+			if err != nil {
+				t.Fatalf("ParseQRCodeURL() error = %v", err)
+			}
+			if got.AccessKey.String() != testKey {
+				t.Errorf("AccessKey = %q, want %q", got.AccessKey, testKey)
+			}
+			tt.want.URL = tt.url
+			tt.want.AccessKey = got.AccessKey
+			if *got != tt.want {
+				t.Errorf("ParseQRCodeURL() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQRCodeURLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{name: "no_payload", url: "https://example.com/qrcode", want: ErrInvalidQRCode},
+		{name: "bad_escape", url: "https://example.com/qrcode?p=" + testKey + "%7|2|1|1|X", want: ErrInvalidQRCode},
+		{name: "bad_key", url: "https://example.com/qrcode?p=" + testKey[:43] + "0|2|1|1|X", want: ErrAccessKeyCheckDigit},
+		{name: "bad_env", url: "https://example.com/qrcode?p=" + testKey + "|2|3|1|X", want: ErrInvalidQRCode},
+		{name: "bad_layout", url: "https://example.com/qrcode?p=" + testKey + "|2|1|1", want: ErrInvalidQRCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseQRCodeURL(tt.url); !errors.Is(err, tt.want) {
+				t.Errorf("ParseQRCodeURL() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -29,10 +29,29 @@ type Receipt struct {
 
 	Subtotal money.BRL `json:"subtotal"`
 	Discount money.BRL `json:"discount"`
-	Total    money.BRL `json:"total"`
-
-	Payments []Payment `json:"payments,omitempty"`
-	Taxes    Taxes     `json:"taxes"`
+	// Freight, Insurance and OtherCosts are charged on top of the items.
+	Freight    money.BRL `json:"freight,omitempty"`
+	Insurance  money.BRL `json:"insurance,omitempty"`
+	OtherCosts money.BRL `json:"other_costs,omitempty"`
+	Total      money.BRL `json:"total"`
+
+	Payments  []Payment  `json:"payments,omitempty"`
+	Change    money.BRL  `json:"change,omitempty"` // paid in excess and given back
+	Taxes     Taxes      `json:"taxes"`
+	TaxTotals *TaxTotals `json:"tax_totals,omitempty"`
+
+	Authorization *Authorization `json:"authorization,omitempty"`
+	// Status is "" when the source does not tell, e.g. an unsigned XML.
+	Status Status  `json:"status,omitempty"`
+	Events []Event `json:"events,omitempty"`
+	// AdditionalInfo is the free text the issuer and the tax authority
+	// added to the invoice, infAdFisco and infCpl.
+	AdditionalInfo string `json:"additional_info,omitempty"`
 
 	RawHTML []byte `json:"-"`
 }
+
+// Cancelled reports whether the invoice was cancelled after issue.
+func (r *Receipt) Cancelled() bool {
+	return r.Status == StatusCancelled
+}
@@ -0,0 +1,73 @@
+package invoice
+
+import (
+	"strings"
+	"time"
+)
+
+// Status is the current state of an invoice at SEFAZ. Invoices can be
+// cancelled after the sale, in which case they are not spending.
+type Status string
+
+const (
+	StatusAuthorized Status = "authorized"
+	StatusCancelled  Status = "cancelled"
+	StatusDenied     Status = "denied"
+	// StatusContingencyPending is an invoice issued offline that SEFAZ has
+	// not authorized yet.
+	StatusContingencyPending Status = "contingency_pending"
+)
+
+// ParseStatus maps the portal's status wording, such as "AUTORIZADA" or
+// "CANCELADA", to a Status. Unknown values yield "".
+func ParseStatus(s string) Status {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(s, "CANCELAD"):
+		return StatusCancelled
+	case strings.HasPrefix(s, "DENEGAD"):
+		return StatusDenied
+	case strings.Contains(s, "PENDENTE") || strings.Contains(s, "CONTINGÊNCIA"):
+		return StatusContingencyPending
+	case strings.HasPrefix(s, "AUTORIZAD"):
+		return StatusAuthorized
+	}
+	return ""
+}
+
+// StatusFromCode maps a protocol cStat to a Status. Codes that are not a
+// final status yield "".
+func StatusFromCode(cStat int) Status {
+	switch cStat {
+	case CStatAuthorized:
+		return StatusAuthorized
+	case CStatCancelled, CStatCancelledLate:
+		return StatusCancelled
+	case CStatDenied, 301, 302, 303:
+		return StatusDenied
+	}
+	return ""
+}
+
+// EventType is the tpEvento code of an invoice event.
+type EventType string
+
+const (
+	EventCorrection   EventType = "110110"
+	EventCancellation EventType = "110111"
+	// EventSubstitution cancels the invoice in favor of another one.
+	EventSubstitution EventType = "110112"
+)
+
+// Cancels reports whether events of type t cancel the invoice.
+func (t EventType) Cancels() bool {
+	return t == EventCancellation || t == EventSubstitution
+}
+
+// Event is an event registered for the invoice after its issue.
+type Event struct {
+	Type        EventType `json:"type"`
+	Description string    `json:"description,omitempty"`
+	Protocol    string    `json:"protocol,omitempty"`
+	At          time.Time `json:"at"`
+}
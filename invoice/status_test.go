@@ -0,0 +1,44 @@
+package invoice
+
+import "testing"
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Status
+	}{
+		{"AUTORIZADA", StatusAuthorized},
+		{" Autorizada ", StatusAuthorized},
+		{"CANCELADA", StatusCancelled},
+		{"DENEGADA", StatusDenied},
+		{"PENDENTE DE AUTORIZAÇÃO", StatusContingencyPending},
+		{"EMITIDA EM CONTINGÊNCIA", StatusContingencyPending},
+		{"", ""},
+		{"INUTILIZADA", ""},
+	}
+	for _, tt := range tests {
+		if got := ParseStatus(tt.in); got != tt.want {
+			t.Errorf("ParseStatus(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStatusFromCode(t *testing.T) {
+	tests := []struct {
+		cStat int
+		want  Status
+	}{
+		{CStatAuthorized, StatusAuthorized},
+		{CStatCancelled, StatusCancelled},
+		{CStatCancelledLate, StatusCancelled},
+		{CStatDenied, StatusDenied},
+		{302, StatusDenied},
+		{204, ""},
+		{0, ""},
+	}
+	for _, tt := range tests {
+		if got := StatusFromCode(tt.cStat); got != tt.want {
+			t.Errorf("StatusFromCode(%d) = %q, want %q", tt.cStat, got, tt.want)
+		}
+	}
+}
@@ -11,10 +11,23 @@ type Taxes struct {
 	Amount money.BRL `json:"amount"`
 }
 
+// TaxTotals holds the invoice-wide tax values of the NF-e totals group.
 type TaxTotals struct {
-	IPI    money.BRL `json:"ipi,omitempty"`
-	PIS    money.BRL `json:"pis,omitempty"`
-	ICMS   money.BRL `json:"icms,omitempty"`
-	COFINS money.BRL `json:"cofins,omitempty"`
-	Other  money.BRL `json:"other,omitempty"`
+	ICMSBase money.BRL `json:"icms_base,omitempty"`
+	ICMS     money.BRL `json:"icms,omitempty"`
+	ICMSST   money.BRL `json:"icms_st,omitempty"`
+	IPI      money.BRL `json:"ipi,omitempty"`
+	PIS      money.BRL `json:"pis,omitempty"`
+	COFINS   money.BRL `json:"cofins,omitempty"`
+	// ImportTax is the import tax (II, vII).
+	ImportTax money.BRL `json:"import_tax,omitempty"`
+}
+
+// Surcharges returns the taxes added on top of the items' value, unlike
+// ICMS, PIS and COFINS, which are included in it.
+func (t *TaxTotals) Surcharges() money.BRL {
+	if t == nil {
+		return 0
+	}
+	return t.ICMSST.Add(t.IPI).Add(t.ImportTax)
 }
@@ -0,0 +1,22 @@
+package invoice
+
+// ufCodes maps IBGE state codes (cUF) to state abbreviations.
+var ufCodes = map[int]string{
+	11: "RO", 12: "AC", 13: "AM", 14: "RR", 15: "PA", 16: "AP", 17: "TO",
+	21: "MA", 22: "PI", 23: "CE", 24: "RN", 25: "PB", 26: "PE", 27: "AL", 28: "SE", 29: "BA",
+	31: "MG", 32: "ES", 33: "RJ", 35: "SP",
+	41: "PR", 42: "SC", 43: "RS",
+	50: "MS", 51: "MT", 52: "GO", 53: "DF",
+}
+
+// StateByCode returns the state abbreviation for an IBGE UF code.
+func StateByCode(code int) (string, bool) {
+	uf, ok := ufCodes[code]
+	return uf, ok
+}
+
+// State returns the abbreviation of the state that issued the key, or "" if unknown.
+func (k AccessKey) State() string {
+	uf, _ := StateByCode(k.UF)
+	return uf
+}
@@ -0,0 +1,217 @@
+package invoice
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/glwbr/brisa/document"
+	"github.com/glwbr/brisa/money"
+	"github.com/glwbr/brisa/parse"
+)
+
+// Severity ranks how much a Finding undermines trust in a Receipt.
+type Severity string
+
+const (
+	// SeverityError means the receipt is internally inconsistent, usually
+	// because a field was missed or misread.
+	SeverityError Severity = "error"
+	// SeverityWarning flags values that are suspicious but may be legitimate.
+	SeverityWarning Severity = "warning"
+)
+
+// Rule names a consistency check run by Validate.
+type Rule string
+
+const (
+	RuleItemsSubtotal Rule = "items_subtotal"
+	RuleTotal         Rule = "total"
+	RulePayments      Rule = "payments"
+	RuleItemTotal     Rule = "item_total"
+	RuleLineNumbers   Rule = "line_numbers"
+	RuleAccessKey     Rule = "access_key"
+	RuleKeyUF         Rule = "key_uf"
+	RuleKeyCNPJ       Rule = "key_cnpj"
+	RuleKeyDate       Rule = "key_date"
+	RuleKeyNumber     Rule = "key_number"
+	RuleGTIN          Rule = "gtin"
+	RuleIssuerCNPJ    Rule = "issuer_cnpj"
+	RuleConsumerDoc   Rule = "consumer_document"
+)
+
+// Finding reports a failed rule. Line is the item line number for per-item
+// rules and zero otherwise.
+type Finding struct {
+	Rule     Rule     `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line,omitempty"`
+}
+
+func (f Finding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s: %s (item %d): %s", f.Severity, f.Rule, f.Line, f.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Rule, f.Message)
+}
+
+// Validate cross-checks the fields of r and returns one Finding per failed
+// rule, or nil when the receipt is consistent. Rules whose inputs are absent
+// (e.g. no payments were scraped) are skipped.
+func Validate(r *Receipt) []Finding {
+	var v validator
+	v.totals(r)
+	v.items(r.Items)
+	v.accessKey(r)
+	v.documents(r)
+	return v.findings
+}
+
+type validator struct {
+	findings []Finding
+}
+
+func (v *validator) add(rule Rule, sev Severity, line int, format string, args ...any) {
+	v.findings = append(v.findings, Finding{
+		Rule:     rule,
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+		Line:     line,
+	})
+}
+
+func (v *validator) totals(r *Receipt) {
+	var items money.BRL
+	for _, item := range r.Items {
+		items = items.Add(item.Total)
+	}
+	switch {
+	case len(r.Items) == 0 && r.Total != 0:
+		v.add(RuleItemsSubtotal, SeverityError, 0, "receipt totals %s but has no items", r.Total)
+	case len(r.Items) > 0 && items != r.Subtotal:
+		v.add(RuleItemsSubtotal, SeverityError, 0, "items sum to %s, subtotal is %s", items, r.Subtotal)
+	}
+
+	charges := r.Freight.Add(r.Insurance).Add(r.OtherCosts).Add(r.TaxTotals.Surcharges())
+	if want := r.Subtotal.Sub(r.Discount).Add(charges); want != r.Total {
+		if charges != 0 {
+			v.add(RuleTotal, SeverityError, 0, "subtotal %s minus discount %s plus charges %s is %s, total is %s",
+				r.Subtotal, r.Discount, charges, want, r.Total)
+		} else {
+			v.add(RuleTotal, SeverityError, 0, "subtotal %s minus discount %s is %s, total is %s",
+				r.Subtotal, r.Discount, want, r.Total)
+		}
+	}
+
+	if len(r.Payments) > 0 {
+		var paid money.BRL
+		for _, p := range r.Payments {
+			paid = paid.Add(p.Amount)
+		}
+		switch {
+		case paid < r.Total:
+			v.add(RulePayments, SeverityError, 0, "payments sum to %s, less than total %s", paid, r.Total)
+		case r.Change != 0 && paid.Sub(r.Change) != r.Total:
+			v.add(RulePayments, SeverityError, 0, "payments sum to %s, minus change %s is not total %s", paid, r.Change, r.Total)
+		}
+	}
+}
+
+func (v *validator) items(items []Item) {
+	for i, item := range items {
+		if want := i + 1; item.LineNumber != want {
+			v.add(RuleLineNumbers, SeverityError, item.LineNumber,
+				"item at position %d has line number %d, want %d", want, item.LineNumber, want)
+		}
+
+		// The unit price is rounded to cents, so each unit may be off by half
+		// a cent on top of the rounding of the line total.
+		expected := float64(item.UnitPrice) * item.Quantity
+		tolerance := math.Ceil(item.Quantity/2) + 1
+		if math.Abs(expected-float64(item.Total)) > tolerance {
+			v.add(RuleItemTotal, SeverityWarning, item.LineNumber, "%g x %s is %s, item total is %s",
+				item.Quantity, item.UnitPrice, money.BRL(math.Round(expected)), item.Total)
+		}
+
+		if item.GTIN != "" && !ValidGTIN(item.GTIN) {
+			v.add(RuleGTIN, SeverityWarning, item.LineNumber, "invalid GTIN %q", item.GTIN)
+		}
+	}
+}
+
+func (v *validator) accessKey(r *Receipt) {
+	key, err := ParseAccessKey(r.Key)
+	if err != nil {
+		v.add(RuleAccessKey, SeverityError, 0, "%v", err)
+		return
+	}
+
+	if state := r.Issuer.Address.State; state != "" && !strings.EqualFold(state, key.State()) {
+		v.add(RuleKeyUF, SeverityError, 0, "key UF is %s, issuer state is %s", key.State(), state)
+	}
+
+	if cnpj := document.Clean(r.Issuer.CNPJ); cnpj != "" && fmt.Sprintf("%014s", cnpj) != key.CNPJ {
+		v.add(RuleKeyCNPJ, SeverityError, 0, "key CNPJ is %s, issuer CNPJ is %s", key.CNPJ, r.Issuer.CNPJ)
+	}
+
+	if d := r.IssueDate; !d.IsZero() && (d.Year() != key.Year || d.Month() != key.Month) {
+		v.add(RuleKeyDate, SeverityError, 0, "key period is %04d-%02d, issued %s",
+			key.Year, key.Month, d.Format("2006-01"))
+	}
+
+	if r.Series != "" && parse.Int(r.Series) != key.Series {
+		v.add(RuleKeyNumber, SeverityError, 0, "key series is %d, receipt series is %s", key.Series, r.Series)
+	}
+	if r.ReceiptNumber != "" && parse.Int(r.ReceiptNumber) != key.Number {
+		v.add(RuleKeyNumber, SeverityError, 0, "key number is %d, receipt number is %s", key.Number, r.ReceiptNumber)
+	}
+}
+
+func (v *validator) documents(r *Receipt) {
+	if cnpj := r.Issuer.CNPJ; cnpj != "" {
+		if err := validateDocument(cnpj); err != nil {
+			v.add(RuleIssuerCNPJ, SeverityError, 0, "%s: %v", cnpj, err)
+		}
+	}
+	if doc := r.Consumer.Document; doc != "" && !document.IsMasked(doc) {
+		if err := validateDocument(doc); err != nil {
+			v.add(RuleConsumerDoc, SeverityError, 0, "%s: %v", doc, err)
+		}
+	}
+}
+
+// validateDocument checks s as a CPF when it has 11 characters and as a
+// CNPJ otherwise.
+func validateDocument(s string) error {
+	if len(document.Clean(s)) == 11 {
+		_, err := document.ParseCPF(s)
+		return err
+	}
+	_, err := document.ParseCNPJ(s)
+	return err
+}
+
+// ValidGTIN reports whether s is a GTIN-8, -12, -13 or -14 with a correct
+// GS1 check digit.
+func ValidGTIN(s string) bool {
+	switch len(s) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	if !isDigits(s) {
+		return false
+	}
+
+	sum := 0
+	for i := len(s) - 2; i >= 0; i-- {
+		d := int(s[i] - '0')
+		// Weights alternate 3, 1, ... starting next to the check digit.
+		if (len(s)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(s[len(s)-1]-'0')
+}
@@ -0,0 +1,212 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+)
+
+func validReceipt() *Receipt {
+	return &Receipt{
+		Key:           testKey,
+		Portal:        PortalBA,
+		IssueDate:     time.Date(2025, 11, 19, 20, 31, 22, 0, time.FixedZone("", -3*3600)),
+		ReceiptNumber: "321219",
+		Series:        "8",
+		Issuer: Issuer{
+			Name:    "SENDAS DISTRIBUIDORA S/A",
+			CNPJ:    "06.057.223/0314-84",
+			Address: Address{State: "BA"},
+		},
+		Items: []Item{
+			{LineNumber: 1, Quantity: 2, UnitPrice: 2799, Total: 5598, GTIN: "7896006716129"},
+			{LineNumber: 2, Quantity: 1.235, UnitPrice: 699, Total: 863},
+			{LineNumber: 3, Quantity: 12, UnitPrice: 479, Total: 5748},
+		},
+		Subtotal: 12209,
+		Discount: 209,
+		Total:    12000,
+		Payments: []Payment{{Method: PaymentPix, Amount: 5000}, {Method: PaymentCreditCard, Amount: 7000}},
+	}
+}
+
+func TestValidateConsistent(t *testing.T) {
+	if got := Validate(validReceipt()); len(got) != 0 {
+		t.Errorf("Validate() = %v, want no findings", got)
+	}
+}
+
+func TestValidateCharges(t *testing.T) {
+	r := validReceipt()
+	r.Freight = 500
+	r.TaxTotals = &TaxTotals{ICMS: 1527, IPI: 100}
+	r.Total = 12600
+	r.Payments = []Payment{{Method: PaymentPix, Amount: 12600}}
+	if got := Validate(r); len(got) != 0 {
+		t.Errorf("Validate() = %v, want no findings", got)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(r *Receipt)
+		rule     Rule
+		severity Severity
+		line     int
+	}{
+		{
+			name:     "missing_item",
+			mutate:   func(r *Receipt) { r.Items = r.Items[:2] },
+			rule:     RuleItemsSubtotal,
+			severity: SeverityError,
+		},
+		{
+			name:     "no_items",
+			mutate:   func(r *Receipt) { r.Items = nil },
+			rule:     RuleItemsSubtotal,
+			severity: SeverityError,
+		},
+		{
+			name:     "zero_total",
+			mutate:   func(r *Receipt) { r.Total = 0; r.Payments = nil },
+			rule:     RuleTotal,
+			severity: SeverityError,
+		},
+		{
+			name:     "freight_not_in_total",
+			mutate:   func(r *Receipt) { r.Freight = 500 },
+			rule:     RuleTotal,
+			severity: SeverityError,
+		},
+		{
+			name:     "underpaid",
+			mutate:   func(r *Receipt) { r.Payments = r.Payments[:1] },
+			rule:     RulePayments,
+			severity: SeverityError,
+		},
+		{
+			name:     "wrong_change",
+			mutate:   func(r *Receipt) { r.Payments[1].Amount = 8000; r.Change = 500 },
+			rule:     RulePayments,
+			severity: SeverityError,
+		},
+		{
+			name:     "item_total",
+			mutate:   func(r *Receipt) { r.Items[2].UnitPrice = 497; r.Items[2].Total = 5748 },
+			rule:     RuleItemTotal,
+			severity: SeverityWarning,
+			line:     3,
+		},
+		{
+			name:     "line_gap",
+			mutate:   func(r *Receipt) { r.Items[1].LineNumber = 4 },
+			rule:     RuleLineNumbers,
+			severity: SeverityError,
+			line:     4,
+		},
+		{
+			name:     "invalid_key",
+			mutate:   func(r *Receipt) { r.Key = testKey[:43] + "0" },
+			rule:     RuleAccessKey,
+			severity: SeverityError,
+		},
+		{
+			name:     "key_uf",
+			mutate:   func(r *Receipt) { r.Issuer.Address.State = "SP" },
+			rule:     RuleKeyUF,
+			severity: SeverityError,
+		},
+		{
+			name:     "key_cnpj",
+			mutate:   func(r *Receipt) { r.Issuer.CNPJ = "06057223000171" },
+			rule:     RuleKeyCNPJ,
+			severity: SeverityError,
+		},
+		{
+			name:     "key_date",
+			mutate:   func(r *Receipt) { r.IssueDate = r.IssueDate.AddDate(0, 1, 0) },
+			rule:     RuleKeyDate,
+			severity: SeverityError,
+		},
+		{
+			name:     "key_number",
+			mutate:   func(r *Receipt) { r.ReceiptNumber = "321218" },
+			rule:     RuleKeyNumber,
+			severity: SeverityError,
+		},
+		{
+			name:     "gtin",
+			mutate:   func(r *Receipt) { r.Items[0].GTIN = "7896006716128" },
+			rule:     RuleGTIN,
+			severity: SeverityWarning,
+			line:     1,
+		},
+		{
+			name:     "issuer_cnpj",
+			mutate:   func(r *Receipt) { r.Issuer.CNPJ = "06057223031485" },
+			rule:     RuleIssuerCNPJ,
+			severity: SeverityError,
+		},
+		{
+			name:     "consumer_document",
+			mutate:   func(r *Receipt) { r.Consumer.Document = "12345678908" },
+			rule:     RuleConsumerDoc,
+			severity: SeverityError,
+		},
+		{
+			name:     "masked_consumer_document_skipped",
+			mutate:   func(r *Receipt) { r.Consumer.Document = "***.456.789-**"; r.Items[0].GTIN = "7896006716128" },
+			rule:     RuleGTIN,
+			severity: SeverityWarning,
+			line:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validReceipt()
+			tt.mutate(r)
+
+			// A corrupted field may break several rules (a wrong issuer CNPJ
+			// also mismatches the key); only the rule under test must fire.
+			var found *Finding
+			for _, f := range Validate(r) {
+				if f.Rule == tt.rule {
+					found = &f
+				} else if tt.rule != RuleIssuerCNPJ {
+					t.Errorf("unexpected finding %s", f)
+				}
+			}
+			if found == nil {
+				t.Fatalf("Validate() has no %s finding", tt.rule)
+			}
+			if found.Severity != tt.severity || found.Line != tt.line {
+				t.Errorf("Validate() = %+v, want severity %s line %d", *found, tt.severity, tt.line)
+			}
+			if found.Message == "" {
+				t.Error("Finding.Message is empty")
+			}
+		})
+	}
+}
+
+func TestValidGTIN(t *testing.T) {
+	tests := []struct {
+		gtin string
+		want bool
+	}{
+		{"7896006716129", true},
+		{"96385074", true},
+		{"036000291452", true},
+		{"17896006716126", true},
+		{"7896006716128", false},
+		{"789600671612", false},
+		{"SEM GTIN", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidGTIN(tt.gtin); got != tt.want {
+			t.Errorf("ValidGTIN(%q) = %v, want %v", tt.gtin, got, tt.want)
+		}
+	}
+}
@@ -85,6 +85,57 @@ func Parse(s string) (BRL, error) {
 	return FromFloat(f), nil
 }
 
+// ParseDecimal converts a dot-separated decimal (e.g., "1234.56", as used in
+// NF-e XML and QR codes) to a BRL value without going through float.
+// Digits beyond the cents are rounded half away from zero.
+func ParseDecimal(s string) (BRL, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	intPart, frac, _ := strings.Cut(s, ".")
+	if intPart == "" || !isDigits(intPart) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+	}
+
+	reais, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	frac += "00"
+	cents := int64(frac[0]-'0')*10 + int64(frac[1]-'0')
+	if len(frac) > 2 && frac[2] >= '5' {
+		cents++
+	}
+
+	v := BRL(reais*100 + cents)
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// Decimal formats the value as a dot-separated decimal with the given number
+// of fractional digits (at least 2), e.g. "1234.56" or "27.9900000000", as
+// required by the NF-e layout. It works on the integer cents only.
+func (b BRL) Decimal(places int) string {
+	sign := ""
+	if b < 0 {
+		sign = "-"
+	}
+	// Use uint64 so that -MinInt64 does not overflow.
+	value := uint64(b)
+	if b < 0 {
+		value = -value
+	}
+	s := fmt.Sprintf("%s%d.%02d", sign, value/100, value%100)
+	if places > 2 {
+		s += strings.Repeat("0", places-2)
+	}
+	return s
+}
+
 // Ratio calculates the ratio of this BRL value to another
 func (b BRL) Ratio(other BRL) (float64, error) {
 	if other == 0 {
@@ -109,6 +160,15 @@ func abs(x int64) int64 {
 	return x
 }
 
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // formatThousands formats an integer with thousand separators
 func formatThousands(n int64) string {
 	s := fmt.Sprintf("%d", n)
@@ -1,6 +1,9 @@
 package money
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestParseBRL(t *testing.T) {
 	tests := []struct {
@@ -33,3 +36,60 @@ func TestBRLString(t *testing.T) {
 		t.Fatalf("String() = %q; want %q", got, want)
 	}
 }
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  BRL
+	}{
+		{"527.84", 52784},
+		{"10", 1000},
+		{"0.5", 50},
+		{"3.4550000000", 346},
+		{"-1.01", -101},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.input)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q) error = %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseDecimal(%q) = %d; want %d", tt.input, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "1,50", "1.2.3", "abc"} {
+		if _, err := ParseDecimal(bad); err == nil {
+			t.Errorf("ParseDecimal(%q) expected error", bad)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		value  BRL
+		places int
+		want   string
+	}{
+		{52784, 2, "527.84"},
+		{5, 2, "0.05"},
+		{-101, 2, "-1.01"},
+		{2799, 10, "27.9900000000"},
+		{0, 0, "0.00"},
+		// Beyond float64 precision: any float conversion would corrupt these.
+		{math.MaxInt64, 2, "92233720368547758.07"},
+		{math.MinInt64, 2, "-92233720368547758.08"},
+		{9007199254740993, 2, "90071992547409.93"},
+	}
+	for _, tt := range tests {
+		if got := tt.value.Decimal(tt.places); got != tt.want {
+			t.Errorf("BRL(%d).Decimal(%d) = %q; want %q", int64(tt.value), tt.places, got, tt.want)
+		}
+		if tt.places == 2 {
+			back, err := ParseDecimal(tt.want)
+			if err == nil && back != tt.value {
+				t.Errorf("ParseDecimal(%q) = %d; want %d", tt.want, back, tt.value)
+			}
+		}
+	}
+}
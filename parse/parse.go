@@ -58,7 +58,8 @@ func Percent(s string) float64 {
 
 // BrazilianDate parses common Brazilian date formats.
 func BrazilianDate(s string) (time.Time, error) {
-	s = Text(s)
+	// Event times read "15/03/2025 às 20:59:13-03:00".
+	s = strings.Replace(Text(s), " às ", " ", 1)
 	layouts := []string{
 		"02/01/2006 15:04:05-07:00",
 		"02/01/2006 15:04:05",
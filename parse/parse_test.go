@@ -1,6 +1,9 @@
 package parse
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestDigits(t *testing.T) {
 	tests := []struct {
@@ -60,3 +63,23 @@ func TestFirstNonEmpty(t *testing.T) {
 		t.Errorf("FirstNonEmpty() = %q, want empty", got)
 	}
 }
+
+func TestBrazilianDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"19/11/2025 20:31:22-03:00", time.Date(2025, 11, 19, 20, 31, 22, 0, time.FixedZone("", -3*3600))},
+		{"15/03/2025 às 20:59:13-03:00", time.Date(2025, 3, 15, 20, 59, 13, 0, time.FixedZone("", -3*3600))},
+		{"15/03/2025", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := BrazilianDate(tt.in)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("BrazilianDate(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := BrazilianDate("ontem"); err == nil {
+		t.Error("BrazilianDate(\"ontem\") error = nil")
+	}
+}
@@ -0,0 +1,44 @@
+package ba
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/glwbr/brisa/scraper"
+)
+
+var ErrAdditionalInfoTabNotFound = errors.New("additional info tab not found")
+
+const (
+	sectionInfFisco = "Informações Adicionais de Interesse do Fisco"
+	sectionInfCpl   = "Informações Complementares de Interesse do Contribuinte"
+)
+
+// ParseAdditionalInfoTab parses the Informações Adicionais tab, returning
+// the notes for the tax authority (infAdFisco) followed by those for the
+// consumer (infCpl), one per line.
+func ParseAdditionalInfoTab(htmlBytes []byte) (string, error) {
+	doc, err := scraper.ParseHTML(htmlBytes)
+	if err != nil {
+		return "", err
+	}
+
+	infAdic := doc.Find("#InfAdic")
+	if infAdic.Length() == 0 {
+		return "", ErrAdditionalInfoTabNotFound
+	}
+	return parseAdditionalInfo(infAdic), nil
+}
+
+// parseAdditionalInfo joins the notes of an InfAdic section.
+func parseAdditionalInfo(infAdic *goquery.Selection) string {
+	sections := buildSectionIndex(infAdic)
+	var notes []string
+	for _, title := range []string{sectionInfFisco, sectionInfCpl} {
+		if v := strings.TrimSpace(sections[title]["Descrição"]); v != "" {
+			notes = append(notes, v)
+		}
+	}
+	return strings.Join(notes, "\n")
+}
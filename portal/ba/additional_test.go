@@ -0,0 +1,55 @@
+package ba
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParseAdditionalInfoTab(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/additional_info_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseAdditionalInfoTab(page)
+	if err != nil {
+		t.Fatalf("ParseAdditionalInfoTab() error = %v", err)
+	}
+
+	want := "Documento emitido por ME ou EPP optante pelo Simples Nacional.\n" +
+		"PDV: 012 OPERADOR: 4471 CUPOM: 321219 Trib aprox R$ 59,37 Federal e R$ 38,99 Estadual Fonte: IBPT"
+	if got != want {
+		t.Errorf("ParseAdditionalInfoTab() = %q, want %q", got, want)
+	}
+
+	if _, err := ParseAdditionalInfoTab([]byte("<html></html>")); !errors.Is(err, ErrAdditionalInfoTabNotFound) {
+		t.Errorf("ParseAdditionalInfoTab() of another page error = %v, want %v", err, ErrAdditionalInfoTabNotFound)
+	}
+}
+
+func TestParseNFeTabAdditionalInfo(t *testing.T) {
+	nfePage, err := os.ReadFile("../../testdata/nfe_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseNFeTab(nfePage)
+	if err != nil {
+		t.Fatalf("ParseNFeTab() error = %v", err)
+	}
+	if r.AdditionalInfo != "" {
+		t.Errorf("AdditionalInfo = %q without the section, want empty", r.AdditionalInfo)
+	}
+
+	// A page that holds both sections.
+	infPage, err := os.ReadFile("../../testdata/additional_info_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = ParseNFeTab(append(nfePage, infPage...))
+	if err != nil {
+		t.Fatalf("ParseNFeTab() error = %v", err)
+	}
+	if want, _ := ParseAdditionalInfoTab(infPage); r.AdditionalInfo != want || want == "" {
+		t.Errorf("AdditionalInfo = %q, want %q", r.AdditionalInfo, want)
+	}
+}
@@ -0,0 +1,487 @@
+// Package batest provides an in-process fake of the BA SEFAZ NFC-e portal
+// for offline end-to-end tests of ba.Scraper.
+//
+// The fake mimics the ASP.NET flow of the real site: it issues a session
+// cookie and per-page __VIEWSTATE/__EVENTVALIDATION tokens, rejects
+// postbacks whose tokens do not match, serves a deterministic captcha and
+// walks the access key form → DANFE → tabs → products pages. Page bodies
+// come from the saved portal pages in the repository's testdata directory.
+package batest
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/glwbr/brisa/parse"
+	"github.com/glwbr/brisa/portal/ba"
+)
+
+// CaptchaAnswer is the solution to every captcha the fake serves.
+const CaptchaAnswer = "K7M2Q"
+
+const sessionCookie = "ASP.NET_SessionId"
+
+// Fixture file names looked up in the fs.FS given to NewServer.
+const (
+	DanfeFixture          = "danfe_view.html"
+	TabsFixture           = "tabs_view.html"
+	ProductsFixture       = "product_service_tab.html"
+	IssuerFixture         = "issuer_tab.html"
+	TotalsFixture         = "totals_tab.html"
+	PaymentFixture        = "payment_tab.html"
+	AdditionalInfoFixture = "additional_info_tab.html"
+)
+
+// Failure scripts how the fake answers an access key submission.
+type Failure int
+
+const (
+	// FailWrongCaptcha rejects the captcha regardless of the answer.
+	FailWrongCaptcha Failure = iota + 1
+	// FailNotFound reports that no NFC-e exists for the key.
+	FailNotFound
+	// FailSessionExpired answers with the portal's expired session page.
+	FailSessionExpired
+	// FailException answers with an ASP.NET server error page (HTTP 500).
+	FailException
+	// FailLayoutChanged serves a tab without its expected content, as after
+	// a portal redesign. Only FailTab uses it.
+	FailLayoutChanged
+)
+
+// Server is a running fake portal. Use URL as the scraper's base URL.
+type Server struct {
+	*httptest.Server
+
+	key   string
+	pages map[string][]byte
+	// cancelledTabs replaces the tabs page once Cancel is called.
+	cancelledTabs []byte
+
+	mu        sync.Mutex
+	sessions  map[string]*session
+	failures  []Failure
+	seq       int
+	requests  map[string]int
+	cancelled bool
+	// tabFailures holds the failures set by FailTab.
+	tabFailures map[ba.Tab]Failure
+}
+
+type session struct {
+	state      string
+	validation string
+	authorized bool
+}
+
+// NewServer starts a fake portal serving the DANFE, tabs, products, issuer,
+// totals and payment fixtures found in fixtures. The invoice it knows is the one shown on the
+// tabs page; see AccessKey.
+func NewServer(fixtures fs.FS) (*Server, error) {
+	return newServer(fixtures, httptest.NewServer)
+}
+
+// NewTLSServer is like NewServer but serves HTTPS with a self-signed
+// certificate; see httptest.Server.Certificate.
+func NewTLSServer(fixtures fs.FS) (*Server, error) {
+	return newServer(fixtures, httptest.NewTLSServer)
+}
+
+func newServer(fixtures fs.FS, start func(http.Handler) *httptest.Server) (*Server, error) {
+	s := &Server{
+		pages:    map[string][]byte{},
+		sessions: map[string]*session{},
+		requests: map[string]int{},
+
+		tabFailures: map[ba.Tab]Failure{},
+	}
+	for _, name := range []string{DanfeFixture, TabsFixture, ProductsFixture, IssuerFixture, TotalsFixture, PaymentFixture, AdditionalInfoFixture} {
+		data, err := fs.ReadFile(fixtures, name)
+		if err != nil {
+			return nil, fmt.Errorf("load fixture: %w", err)
+		}
+		s.pages[name] = stripFormState(data)
+	}
+
+	m := keyLabel.FindSubmatch(s.pages[TabsFixture])
+	if m == nil {
+		return nil, fmt.Errorf("no access key in %s", TabsFixture)
+	}
+	s.key = parse.Digits(string(m[1]))
+	s.cancelledTabs = cancelledPage(s.pages[TabsFixture])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+ba.AccessKeyPage, s.handleAccessKeyPage)
+	mux.HandleFunc("POST "+ba.AccessKeyPage, s.handleSubmit)
+	mux.HandleFunc("GET "+ba.CaptchaEndpoint, s.handleCaptcha)
+	mux.HandleFunc("GET "+ba.DanfePage, s.handleDanfe)
+	mux.HandleFunc("POST "+ba.DanfePage, s.handleViewTabs)
+	mux.HandleFunc("GET "+ba.TabsPage, s.handleTabs)
+	mux.HandleFunc("POST "+ba.TabsPage, s.handleTab)
+	s.Server = start(s.count(mux))
+	return s, nil
+}
+
+// AccessKey returns the key of the invoice the fake knows about.
+func (s *Server) AccessKey() string { return s.key }
+
+// FailNext queues failures for the next access key submissions, one per
+// submission, in order.
+func (s *Server) FailNext(failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failures...)
+}
+
+// FailTab makes every later load of tab fail with f, FailException or
+// FailLayoutChanged.
+func (s *Server) FailTab(tab ba.Tab, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tabFailures[tab] = f
+}
+
+// ExpireSessions forgets every session, as the portal does after its idle
+// timeout. Postbacks from existing clients then get the expired page.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.sessions)
+}
+
+// Cancel makes the invoice show as cancelled from now on, with a
+// cancellation event after its authorization.
+func (s *Server) Cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled = true
+}
+
+// cancellationRow is the events table row of a cancellation.
+const cancellationRow = `<tr class=col-3><td><span class=linha>Cancelamento (Cód.: 110111)</span>` +
+	`<td><span class=linha>229250321790021</span><td><span class=linha>15/03/2025 às 21:12:40-03:00</span>`
+
+// cancelledPage rewrites the tabs page's current situation as cancelled.
+func cancelledPage(tabs []byte) []byte {
+	page := strings.Replace(string(tabs), "AUTORIZADA (Ambiente", "CANCELADA (Ambiente", 1)
+	if i := strings.Index(page, "Autorização de Uso ("); i >= 0 {
+		end := i + strings.Index(page[i:], "</table>")
+		page = page[:end] + cancellationRow + page[end:]
+	}
+	return []byte(page)
+}
+
+// Requests returns how many requests hit path, with any method.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleAccessKeyPage(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(w, r, true)
+	s.renderAccessKeyPage(w, sess, "")
+}
+
+func (s *Server) handleCaptcha(w http.ResponseWriter, r *http.Request) {
+	if s.session(w, r, false) == nil {
+		http.Error(w, "no session", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(captchaImage)
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.postback(w, r)
+	if !ok {
+		return
+	}
+
+	switch s.nextFailure() {
+	case FailWrongCaptcha:
+		s.renderAccessKeyPage(w, sess, "Código incorreto tente novamente.")
+		return
+	case FailNotFound:
+		s.renderAccessKeyPage(w, sess, "NFC-e não encontrada.")
+		return
+	case FailSessionExpired:
+		writeExpired(w)
+		return
+	case FailException:
+		writeException(w)
+		return
+	}
+
+	if !strings.EqualFold(r.PostFormValue(ba.FieldCaptcha), CaptchaAnswer) {
+		s.renderAccessKeyPage(w, sess, "Código incorreto tente novamente.")
+		return
+	}
+	if parse.Digits(r.PostFormValue(ba.FieldAccessKey)) != s.key {
+		s.renderAccessKeyPage(w, sess, "NFC-e não encontrada.")
+		return
+	}
+
+	s.mu.Lock()
+	sess.authorized = true
+	s.mu.Unlock()
+	http.Redirect(w, r, ba.DanfePage, http.StatusFound)
+}
+
+func (s *Server) handleDanfe(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(w, r, false)
+	if sess == nil || !sess.authorized {
+		writeExpired(w)
+		return
+	}
+	s.render(w, sess, s.pages[DanfeFixture])
+}
+
+func (s *Server) handleViewTabs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.postback(w, r); !ok {
+		return
+	}
+	if r.PostFormValue(ba.FieldViewTabs) == "" {
+		writeException(w)
+		return
+	}
+	http.Redirect(w, r, ba.TabsPage, http.StatusFound)
+}
+
+func (s *Server) handleTabs(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(w, r, false)
+	if sess == nil || !sess.authorized {
+		writeExpired(w)
+		return
+	}
+	s.render(w, sess, s.tabsPage())
+}
+
+func (s *Server) tabsPage() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancelled {
+		return s.cancelledTabs
+	}
+	return s.pages[TabsFixture]
+}
+
+func (s *Server) handleTab(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.postback(w, r)
+	if !ok {
+		return
+	}
+
+	// Image buttons post their click coordinates as name.x and name.y.
+	var page []byte
+	var tab ba.Tab
+	for _, t := range tabFixtures {
+		if r.PostFormValue(t.tab.ButtonName()+".x") != "" {
+			tab, page = t.tab, s.pages[t.fixture]
+			break
+		}
+	}
+	if tab == ba.TabNFe {
+		page = s.tabsPage()
+	}
+	if page == nil {
+		writeException(w)
+		return
+	}
+
+	s.mu.Lock()
+	failure := s.tabFailures[tab]
+	s.mu.Unlock()
+	switch failure {
+	case FailException:
+		writeException(w)
+		return
+	case FailLayoutChanged:
+		page = s.tabsPage()
+	}
+	s.render(w, sess, page)
+}
+
+// tabFixtures maps the tabs to the fixtures they serve. The NFe tab is the
+// tabs page itself.
+var tabFixtures = []struct {
+	tab     ba.Tab
+	fixture string
+}{
+	{ba.TabNFe, TabsFixture},
+	{ba.TabProdutos, ProductsFixture},
+	{ba.TabEmitente, IssuerFixture},
+	{ba.TabTotais, TotalsFixture},
+	{ba.TabCobranca, PaymentFixture},
+	{ba.TabInfAdicionais, AdditionalInfoFixture},
+}
+
+// postback validates the session and form state of a POST, answering with
+// the expired or exception page when they do not check out.
+func (s *Server) postback(w http.ResponseWriter, r *http.Request) (*session, bool) {
+	sess := s.session(w, r, false)
+	if sess == nil {
+		writeExpired(w)
+		return nil, false
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	s.mu.Lock()
+	valid := r.PostFormValue("__VIEWSTATE") == sess.state &&
+		r.PostFormValue("__EVENTVALIDATION") == sess.validation
+	s.mu.Unlock()
+	if !valid {
+		// ASP.NET rejects tampered or stale state with a yellow error page.
+		writeException(w)
+		return nil, false
+	}
+	return sess, true
+}
+
+// session returns the caller's session, creating one when create is set.
+func (s *Server) session(w http.ResponseWriter, r *http.Request, create bool) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if sess, ok := s.sessions[c.Value]; ok {
+			return sess
+		}
+	}
+	if !create {
+		return nil
+	}
+
+	s.seq++
+	id := fmt.Sprintf("fakesession%08d", s.seq)
+	sess := &session{}
+	s.sessions[id] = sess
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", HttpOnly: true})
+	return sess
+}
+
+func (s *Server) nextFailure() Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return 0
+	}
+	f := s.failures[0]
+	s.failures = s.failures[1:]
+	return f
+}
+
+// render writes page with fresh form state tokens for sess.
+func (s *Server) render(w http.ResponseWriter, sess *session, page []byte) {
+	s.mu.Lock()
+	s.seq++
+	sess.state = fmt.Sprintf("/wEPDwUKLTk%08dZA==", s.seq)
+	sess.validation = fmt.Sprintf("/wEWAgK%08dAQ==", s.seq)
+	hidden := fmt.Sprintf(`<input type="hidden" name="__VIEWSTATE" id="__VIEWSTATE" value="%s" />`+
+		`<input type="hidden" name="__VIEWSTATEGENERATOR" id="__VIEWSTATEGENERATOR" value="0760F948" />`+
+		`<input type="hidden" name="__EVENTVALIDATION" id="__EVENTVALIDATION" value="%s" />`,
+		sess.state, sess.validation)
+	s.mu.Unlock()
+
+	out := page
+	if loc := formTag.FindIndex(page); loc != nil {
+		out = make([]byte, 0, len(page)+len(hidden))
+		out = append(out, page[:loc[1]]...)
+		out = append(out, hidden...)
+		out = append(out, page[loc[1]:]...)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(out)
+}
+
+func (s *Server) renderAccessKeyPage(w http.ResponseWriter, sess *session, message string) {
+	page := fmt.Sprintf(accessKeyPage, ba.CaptchaEndpoint, message)
+	s.render(w, sess, []byte(page))
+}
+
+func writeExpired(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, expiredPage)
+}
+
+func writeException(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprint(w, exceptionPage)
+}
+
+var (
+	stateInput = regexp.MustCompile(`(?i)<input[^>]*name="?(__VIEWSTATE|__VIEWSTATEGENERATOR|__EVENTVALIDATION)"?[^>]*>`)
+	formTag    = regexp.MustCompile(`(?i)<form[^>]*method="?post"?[^>]*>`)
+	keyLabel   = regexp.MustCompile(`id="?lbl_chave_acesso"?[^>]*>([^<]+)<`)
+)
+
+// stripFormState removes the state tokens saved with a fixture so that
+// render can issue live ones.
+func stripFormState(page []byte) []byte {
+	return stateInput.ReplaceAll(page, nil)
+}
+
+var captchaImage = func() []byte {
+	// A fixed pattern is enough: only the answer is checked.
+	img := image.NewGray(image.Rect(0, 0, 120, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 120; x++ {
+			if (x/6+y/8)%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 40})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 230})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}()
+
+const accessKeyPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Consulta NFC-e</title></head>
+<body>
+<form method="post" action="./NFCEC_consulta_chave_acesso.aspx" id="form1">
+<input type="hidden" name="__EVENTTARGET" id="__EVENTTARGET" value="" />
+<input type="hidden" name="__EVENTARGUMENT" id="__EVENTARGUMENT" value="" />
+<label for="txt_chave_acesso">Chave de Acesso</label>
+<input name="txt_chave_acesso" type="text" maxlength="54" id="txt_chave_acesso" />
+<img id="img_captcha" src="%s" alt="Código de segurança" />
+<input name="txt_cod_antirobo" type="text" maxlength="5" id="txt_cod_antirobo" />
+<span id="lbl_mensagem" class="mensagem">%s</span>
+<input type="submit" name="btn_consulta_completa" value="Consultar" id="btn_consulta_completa" />
+</form>
+</body></html>`
+
+const expiredPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Consulta NFC-e</title></head>
+<body><span id="lbl_mensagem">Sessão expirada. Realize uma nova consulta.</span></body></html>`
+
+const exceptionPage = `<!DOCTYPE html>
+<html><head><title>Object reference not set to an instance of an object.</title></head>
+<body><h1>Server Error in '/servicos/nfce' Application.</h1>
+<h2><i>Object reference not set to an instance of an object.</i></h2>
+<b>Exception Details:</b> System.NullReferenceException: Object reference not set to an instance of an object.
+</body></html>`
@@ -1,6 +1,11 @@
 // Package ba implements the BA SEFAZ NFC-e portal scraper.
 package ba
 
+import "github.com/glwbr/brisa/scraper"
+
+// UFCode is the IBGE code of Bahia, the first two digits of its access keys.
+const UFCode = 29
+
 const (
 	BaseURL         = "https://nfe.sefaz.ba.gov.br"
 	AccessKeyPage   = "/servicos/nfce/Modulos/Geral/NFCEC_consulta_chave_acesso.aspx"
@@ -20,16 +25,37 @@ type Tab string
 
 const (
 	TabNFe      Tab = "nfe"
+	TabEmitente Tab = "emitente"
 	TabProdutos Tab = "produtos"
+	TabTotais   Tab = "totais"
+	TabCobranca Tab = "cobranca"
+	// TabInfAdicionais holds the free-text notes, infAdFisco and infCpl.
+	TabInfAdicionais Tab = "infadicionais"
 )
 
 func (t Tab) ButtonName() string {
 	switch t {
 	case TabNFe:
 		return "btn_aba_nfe"
+	case TabEmitente:
+		return "btn_aba_emitente"
 	case TabProdutos:
 		return "btn_aba_produtos"
+	case TabTotais:
+		return "btn_aba_totais"
+	case TabCobranca:
+		return "btn_aba_cobranca"
+	case TabInfAdicionais:
+		return "btn_aba_infadicionais"
 	default:
 		return ""
 	}
 }
+
+// stage returns the fetch stage reported while loading the tab.
+func (t Tab) stage() scraper.Stage {
+	if t == TabProdutos {
+		return scraper.StageLoadProducts
+	}
+	return scraper.Stage("load_tab_" + string(t))
+}
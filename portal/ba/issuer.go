@@ -0,0 +1,103 @@
+package ba
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/glwbr/brisa/document"
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/parse"
+	"github.com/glwbr/brisa/scraper"
+	"golang.org/x/net/html"
+)
+
+var ErrIssuerTabNotFound = errors.New("issuer tab not found")
+
+// ParseIssuerTab parses the Emitente tab, which has the issuer's full
+// registration data.
+func ParseIssuerTab(htmlBytes []byte) (*invoice.Issuer, error) {
+	doc, err := scraper.ParseHTML(htmlBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	emitente := doc.Find("#Emitente")
+	if emitente.Length() == 0 {
+		return nil, ErrIssuerTabNotFound
+	}
+
+	issuer := parseIssuer(scraper.CollectLabelValues(emitente, map[*html.Node]string{}))
+	return &issuer, nil
+}
+
+// parseIssuer reads the issuer fields present in an Emitente section. The
+// NFe tab has only a few of them; the Emitente tab has them all.
+func parseIssuer(fields map[string]string) invoice.Issuer {
+	street, number, complement := splitStreet(fields["Endereço"])
+	cityCode, city := splitCoded(fields["Município"])
+	return invoice.Issuer{
+		Name:        strings.TrimSpace(fields["Nome / Razão Social"]),
+		CNPJ:        document.Clean(fields["CNPJ"]),
+		TradeName:   strings.TrimSpace(fields["Nome Fantasia"]),
+		StateRegID:  parse.Digits(fields["Inscrição Estadual"]),
+		MunicipalID: strings.TrimSpace(fields["Inscrição Municipal"]),
+		Phone:       parse.Digits(fields["Telefone"]),
+		TaxRegime:   invoice.ParseTaxRegime(fields["Código de Regime Tributário"]),
+		Address: invoice.Address{
+			Street:     street,
+			Number:     number,
+			Complement: complement,
+			District:   strings.TrimSpace(fields["Bairro / Distrito"]),
+			City:       city,
+			CityCode:   cityCode,
+			State:      strings.TrimSpace(fields["UF"]),
+			ZipCode:    parse.Digits(fields["CEP"]),
+		},
+	}
+}
+
+// mergeIssuer copies the fields the Emitente tab filled onto r, keeping
+// those read from the NFe tab where the Emitente tab left them blank.
+func mergeIssuer(r *invoice.Issuer, from *invoice.Issuer) {
+	for dst, src := range map[*string]string{
+		&r.Name:               from.Name,
+		&r.CNPJ:               from.CNPJ,
+		&r.TradeName:          from.TradeName,
+		&r.StateRegID:         from.StateRegID,
+		&r.MunicipalID:        from.MunicipalID,
+		&r.Phone:              from.Phone,
+		&r.Address.Street:     from.Address.Street,
+		&r.Address.Number:     from.Address.Number,
+		&r.Address.Complement: from.Address.Complement,
+		&r.Address.District:   from.Address.District,
+		&r.Address.City:       from.Address.City,
+		&r.Address.CityCode:   from.Address.CityCode,
+		&r.Address.State:      from.Address.State,
+		&r.Address.ZipCode:    from.Address.ZipCode,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+	if from.TaxRegime != "" {
+		r.TaxRegime = from.TaxRegime
+	}
+}
+
+// splitStreet splits the portal's "street, number - complement" address
+// line.
+func splitStreet(s string) (street, number, complement string) {
+	street, rest, _ := strings.Cut(parse.Text(s), ",")
+	number, complement, _ = strings.Cut(rest, " - ")
+	return strings.TrimSpace(street), strings.TrimSpace(number), strings.TrimSpace(complement)
+}
+
+// splitCoded splits values shown as "code - description", such as
+// "2927408 - SALVADOR". Values without a code are returned as description.
+func splitCoded(s string) (code, description string) {
+	code, description, ok := strings.Cut(parse.Text(s), " - ")
+	if !ok || parse.Digits(code) != code {
+		return "", parse.Text(s)
+	}
+	return code, strings.TrimSpace(description)
+}
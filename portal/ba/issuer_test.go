@@ -0,0 +1,105 @@
+package ba
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/glwbr/brisa/invoice"
+)
+
+func TestParseIssuerTab(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/issuer_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := ParseIssuerTab(page)
+	if err != nil {
+		t.Fatalf("ParseIssuerTab() error = %v", err)
+	}
+
+	want := invoice.Issuer{
+		Name:        "SENDAS DISTRIBUIDORA S/A",
+		CNPJ:        "06057223031484",
+		TradeName:   "ASSAI ATACADISTA",
+		StateRegID:  "131694439",
+		MunicipalID: "12345678",
+		Phone:       "7133334444",
+		TaxRegime:   invoice.TaxRegimeNormal,
+		Address: invoice.Address{
+			Street:     "AVENIDA LUIS VIANA FILHO",
+			Number:     "8544",
+			Complement: "LOJA 1",
+			District:   "PARALELA",
+			City:       "SALVADOR",
+			CityCode:   "2927408",
+			State:      "BA",
+			ZipCode:    "41730101",
+		},
+	}
+	if *issuer != want {
+		t.Errorf("ParseIssuerTab() = %+v, want %+v", *issuer, want)
+	}
+
+	if _, err := ParseIssuerTab([]byte("<html></html>")); !errors.Is(err, ErrIssuerTabNotFound) {
+		t.Errorf("ParseIssuerTab() of another page error = %v, want %v", err, ErrIssuerTabNotFound)
+	}
+}
+
+func TestApplyIssuerTabKeepsNFeFields(t *testing.T) {
+	nfePage, err := os.ReadFile("../../testdata/nfe_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseNFeTab(nfePage)
+	if err != nil {
+		t.Fatalf("ParseNFeTab() error = %v", err)
+	}
+	want := r.Issuer.CNPJ
+	if want == "" {
+		t.Fatal("the NFe tab fixture has no issuer CNPJ")
+	}
+
+	page, err := os.ReadFile("../../testdata/issuer_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An Emitente tab that leaves the CNPJ blank.
+	page = bytes.Replace(page, []byte("06.057.223/0314-84"), nil, 1)
+
+	var apply func([]byte, *invoice.Receipt) error
+	for _, o := range optionalTabs {
+		if o.tab == TabEmitente {
+			apply = o.apply
+		}
+	}
+	if err := apply(page, r); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if r.Issuer.CNPJ != want {
+		t.Errorf("Issuer.CNPJ = %q, want %q from the NFe tab", r.Issuer.CNPJ, want)
+	}
+	if r.Issuer.TradeName != "ASSAI ATACADISTA" || r.Issuer.TaxRegime != invoice.TaxRegimeNormal {
+		t.Errorf("Issuer = %+v, want the Emitente tab's trade name and tax regime", r.Issuer)
+	}
+}
+
+func TestSplitStreet(t *testing.T) {
+	tests := []struct {
+		in                         string
+		street, number, complement string
+	}{
+		{"AVENIDA LUIS VIANA FILHO, 8544 - LOJA 1", "AVENIDA LUIS VIANA FILHO", "8544", "LOJA 1"},
+		{"RUA DAS FLORES, SN", "RUA DAS FLORES", "SN", ""},
+		{"RODOVIA BA 099 KM 5", "RODOVIA BA 099 KM 5", "", ""},
+		{"", "", "", ""},
+	}
+	for _, tt := range tests {
+		street, number, complement := splitStreet(tt.in)
+		if street != tt.street || number != tt.number || complement != tt.complement {
+			t.Errorf("splitStreet(%q) = %q, %q, %q, want %q, %q, %q",
+				tt.in, street, number, complement, tt.street, tt.number, tt.complement)
+		}
+	}
+}
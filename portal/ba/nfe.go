@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/glwbr/brisa/document"
 	"github.com/glwbr/brisa/invoice"
 	"github.com/glwbr/brisa/money"
 	"github.com/glwbr/brisa/parse"
@@ -12,14 +13,22 @@ import (
 	"golang.org/x/net/html"
 )
 
-var ErrNFeTabNotFound = errors.New("nfe tab not found")
+var (
+	ErrNFeTabNotFound    = errors.New("nfe tab not found")
+	ErrSituationNotFound = errors.New("situation not found")
+)
 
 const (
 	sectionDados        = "Dados da NFC-e"
 	sectionEmitente     = "Emitente"
 	sectionDestinatario = "Destinatário"
+	sectionEmissao      = "Emissão"
+	sectionSituacao     = "Situação Atual"
 )
 
+// ParseNFeTab parses the NFe tab into a receipt. AdditionalInfo is filled
+// only when the page carries the Informações Adicionais section too: the
+// portal serves it on a tab of its own, see ParseAdditionalInfoTab.
 func ParseNFeTab(htmlBytes []byte) (*invoice.Receipt, error) {
 	doc, err := scraper.ParseHTML(htmlBytes)
 	if err != nil {
@@ -47,15 +56,10 @@ func ParseNFeTab(htmlBytes []byte) (*invoice.Receipt, error) {
 		Portal:        invoice.PortalBA,
 		Series:        strings.TrimSpace(dados["Série"]),
 		ReceiptNumber: strings.TrimSpace(dados["Número"]),
-		Issuer: invoice.Issuer{
-			Name:       strings.TrimSpace(emitente["Nome / Razão Social"]),
-			CNPJ:       parse.Digits(emitente["CNPJ"]),
-			StateRegID: parse.Digits(emitente["Inscrição Estadual"]),
-			Address:    invoice.Address{State: strings.TrimSpace(emitente["UF"])},
-		},
+		Issuer:        parseIssuer(emitente),
 		Consumer: invoice.Consumer{
-			Document: parse.Digits(destinatario["CPF"]),
-			Name:     strings.TrimSpace(destinatario["Nome"]),
+			Document: consumerDocument(destinatario),
+			Name:     strings.TrimSpace(parse.FirstNonEmpty(destinatario["Nome / Razão Social"], destinatario["Nome"])),
 		},
 		Subtotal: total,
 		Total:    total,
@@ -68,9 +72,102 @@ func ParseNFeTab(htmlBytes []byte) (*invoice.Receipt, error) {
 		}
 	}
 
+	parseSituation(nfe, sections[sectionEmissao], r)
+	if infAdic := doc.Find("#InfAdic"); infAdic.Length() > 0 {
+		r.AdditionalInfo = parseAdditionalInfo(infAdic)
+	}
+
 	return r, nil
 }
 
+// ParseNFeStatus reads the status and events of the invoice from its NFe tab.
+// It fails with ErrSituationNotFound when the tab has no Situação Atual
+// block or one with a status it does not know.
+func ParseNFeStatus(htmlBytes []byte) (invoice.Status, []invoice.Event, error) {
+	r, err := ParseNFeTab(htmlBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	if r.Status == "" {
+		return "", nil, ErrSituationNotFound
+	}
+	return r.Status, r.Events, nil
+}
+
+// eventAutorizacao is the code the portal lists the authorization under,
+// among the invoice's events.
+const eventAutorizacao = "110100"
+
+// parseSituation reads the Situação Atual block, a title holding the status
+// and environment followed by the table of the invoice's events, into the
+// receipt's Status, Authorization and Events. Receipts are left as they are
+// if the tab has no such block.
+func parseSituation(nfe *goquery.Selection, emissao map[string]string, r *invoice.Receipt) {
+	cache := map[*html.Node]string{}
+	title := nfe.Find("td.table-titulo-aba-interna").FilterFunction(func(_ int, td *goquery.Selection) bool {
+		return strings.HasPrefix(scraper.CachedText(td, cache), sectionSituacao)
+	}).First()
+	if title.Length() == 0 {
+		return
+	}
+
+	// "Situação Atual: AUTORIZADA (Ambiente de autorização: produção)"
+	situacao := strings.TrimPrefix(scraper.CachedText(title, cache), sectionSituacao)
+	situacao = strings.TrimSpace(strings.TrimPrefix(situacao, ":"))
+	status, env, _ := strings.Cut(situacao, "(")
+	_, env, _ = strings.Cut(env, ":")
+
+	a := &invoice.Authorization{
+		StatusMessage: strings.TrimSpace(status),
+		Environment:   invoice.ParseEnvironment(strings.TrimSuffix(strings.TrimSpace(env), ")")),
+		EmissionType:  invoice.ParseEmissionType(emissao["Tipo de Emissão"]),
+	}
+	r.Authorization = a
+	r.Status = invoice.ParseStatus(status)
+
+	events := title.Closest("table").Next()
+	for _, row := range scraper.CollectTableRows(events, cache) {
+		// "Autorização de Uso (Cód.: 110100)"
+		desc, code, _ := strings.Cut(row["Eventos da NFC-e"], "(")
+		e := invoice.Event{
+			Type:        invoice.EventType(parse.Digits(code)),
+			Description: strings.TrimSpace(desc),
+			Protocol:    row["Protocolo"],
+		}
+		if ts, err := parse.BrazilianDate(row["Data / Hora"]); err == nil {
+			e.At = ts
+		}
+
+		if e.Type == eventAutorizacao || (e.Type == "" && strings.HasPrefix(e.Description, "Autorização de Uso")) {
+			if a.Protocol == "" {
+				a.Protocol = e.Protocol
+				a.AuthorizedAt = e.At
+				a.StatusCode = invoice.CStatAuthorized
+			}
+			continue
+		}
+		r.Events = append(r.Events, e)
+		if e.Type.Cancels() {
+			r.Status = invoice.StatusCancelled
+		}
+	}
+
+	if r.Status == "" && a.Protocol == "" && a.Contingency() {
+		r.Status = invoice.StatusContingencyPending
+	}
+}
+
+// consumerDocument returns the recipient's CPF or CNPJ. Letters of
+// alphanumeric CNPJs and the asterisks of masked CPFs are kept so Validate
+// can tell them apart; the all-zero placeholder of anonymous sales is dropped.
+func consumerDocument(dest map[string]string) string {
+	doc := parse.FirstNonEmpty(dest["CPF"], dest["CNPJ"], dest["CPF / CNPJ"])
+	if document.IsZero(doc) {
+		return ""
+	}
+	return document.Clean(doc)
+}
+
 type sections map[string]map[string]string
 
 func (s sections) firstValue(label string) string {
@@ -0,0 +1,187 @@
+package ba
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	bhttp "github.com/glwbr/brisa/internal/http"
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/scraper"
+)
+
+// fetchLatin1 serves page, encoded in ISO-8859-1, with contentType and
+// returns the body the client decodes.
+func fetchLatin1(t *testing.T, page []byte, contentType string) []byte {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(page)
+	}))
+	defer srv.Close()
+
+	c, err := bhttp.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Get(context.Background(), "/", nil)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	body, err := resp.Body()
+	if err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	return body
+}
+
+func TestBuildSectionIndexLatin1(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/nfe_tab_latin1.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{name: "header", contentType: "text/html; charset=ISO-8859-1"},
+		{name: "meta", contentType: "text/html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := scraper.ParseHTML(fetchLatin1(t, page, tt.contentType))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := buildSectionIndex(doc.Find("#NFe"))
+
+			if _, ok := s[sectionDestinatario]; !ok {
+				t.Errorf("no %q section in %v", sectionDestinatario, s)
+			}
+			if got := s[sectionDados]["Série"]; got != "8" {
+				t.Errorf("Série = %q, want %q", got, "8")
+			}
+			if got := s[sectionEmitente]["Inscrição Estadual"]; got == "" {
+				t.Errorf("Inscrição Estadual is empty")
+			}
+		})
+	}
+}
+
+func TestCheckForErrorsLatin1(t *testing.T) {
+	page := []byte("<html><body><span>Chave de acesso inv\xe1lida</span></body></html>")
+	body := fetchLatin1(t, page, "text/html; charset=iso-8859-1")
+	if err := checkForErrors(body); !errors.Is(err, scraper.ErrInvalidAccessKey) {
+		t.Errorf("checkForErrors() = %v, want %v", err, scraper.ErrInvalidAccessKey)
+	}
+}
+
+func TestParseNFeTabAuthorization(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/nfe_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseNFeTab(page)
+	if err != nil {
+		t.Fatalf("ParseNFeTab() error = %v", err)
+	}
+
+	want := &invoice.Authorization{
+		Protocol:      "229251430293306",
+		AuthorizedAt:  time.Date(2025, 11, 19, 20, 31, 23, 0, time.FixedZone("", -3*60*60)),
+		StatusCode:    invoice.CStatAuthorized,
+		StatusMessage: "AUTORIZADA",
+		Environment:   invoice.EnvironmentProduction,
+		EmissionType:  invoice.EmissionNormal,
+	}
+	got := r.Authorization
+	if got == nil {
+		t.Fatal("Authorization = nil")
+	}
+	if !got.AuthorizedAt.Equal(want.AuthorizedAt) {
+		t.Errorf("AuthorizedAt = %v, want %v", got.AuthorizedAt, want.AuthorizedAt)
+	}
+	got.AuthorizedAt = want.AuthorizedAt
+	if *got != *want {
+		t.Errorf("Authorization = %+v, want %+v", *got, *want)
+	}
+	if got.Contingency() {
+		t.Errorf("Contingency() = true for a normal emission")
+	}
+}
+
+func TestParseNFeTabStatus(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		want       invoice.Status
+		wantEvents []invoice.EventType
+	}{
+		{fixture: "nfe_tab.html", want: invoice.StatusAuthorized},
+		{fixture: "nfe_tab_cancelled.html", want: invoice.StatusCancelled, wantEvents: []invoice.EventType{invoice.EventCancellation}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			page, err := os.ReadFile("../../testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := ParseNFeTab(page)
+			if err != nil {
+				t.Fatalf("ParseNFeTab() error = %v", err)
+			}
+
+			if r.Status != tt.want {
+				t.Errorf("Status = %q, want %q", r.Status, tt.want)
+			}
+			if r.Cancelled() != (tt.want == invoice.StatusCancelled) {
+				t.Errorf("Cancelled() = %v with status %q", r.Cancelled(), r.Status)
+			}
+			var types []invoice.EventType
+			for _, e := range r.Events {
+				types = append(types, e.Type)
+				if e.Protocol == "" || e.At.IsZero() {
+					t.Errorf("event %+v has no protocol or time", e)
+				}
+			}
+			if !slices.Equal(types, tt.wantEvents) {
+				t.Errorf("event types = %v, want %v", types, tt.wantEvents)
+			}
+			// The authorization survives the cancellation.
+			if r.Authorization == nil || r.Authorization.Protocol != "229251430293306" {
+				t.Errorf("Authorization = %+v", r.Authorization)
+			}
+		})
+	}
+}
+
+func TestParseNFeStatusWithoutSituation(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/nfe_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, events, err := ParseNFeStatus(page)
+	if err != nil || status != invoice.StatusAuthorized {
+		t.Fatalf("ParseNFeStatus() = %q, %v, %v, want authorized", status, events, err)
+	}
+
+	tests := map[string][]byte{
+		"missing block":  bytes.Replace(page, []byte("Situação Atual:"), []byte("Situação:"), 1),
+		"unknown status": bytes.Replace(page, []byte("AUTORIZADA"), []byte("EM PROCESSAMENTO"), 1),
+	}
+	for name, page := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := ParseNFeStatus(page); !errors.Is(err, ErrSituationNotFound) {
+				t.Errorf("ParseNFeStatus() error = %v, want %v", err, ErrSituationNotFound)
+			}
+		})
+	}
+}
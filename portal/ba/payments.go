@@ -0,0 +1,66 @@
+package ba
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/glwbr/brisa/document"
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
+	"github.com/glwbr/brisa/scraper"
+	"golang.org/x/net/html"
+)
+
+var ErrPaymentTabNotFound = errors.New("payment tab not found")
+
+// ParsePaymentTab parses the Cobrança tab, which lists one table per
+// payment followed by the change given back, if any.
+func ParsePaymentTab(htmlBytes []byte) (payments []invoice.Payment, change money.BRL, err error) {
+	doc, err := scraper.ParseHTML(htmlBytes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cobranca := doc.Find("#Cobranca")
+	if cobranca.Length() == 0 {
+		return nil, 0, ErrPaymentTabNotFound
+	}
+
+	cache := map[*html.Node]string{}
+	cobranca.Find("table").Each(func(_ int, table *goquery.Selection) {
+		v := scraper.CollectLabelValues(table, cache)
+		if troco, ok := v["Valor do Troco"]; ok {
+			change = parseMoneyOrZero(troco)
+		}
+		method, ok := v["Meio de Pagamento"]
+		if !ok {
+			return
+		}
+		p := invoice.Payment{
+			Method: invoice.ParsePaymentCode(method),
+			Amount: parseMoneyOrZero(v["Valor do Pagamento"]),
+		}
+		card := invoice.Card{
+			Brand:         invoice.ParseCardBrand(labelPrefix(v, "Bandeira da operadora")),
+			AcquirerCNPJ:  document.Clean(labelPrefix(v, "CNPJ da Credenciadora")),
+			Authorization: strings.TrimSpace(labelPrefix(v, "Número de autorização")),
+		}
+		if card != (invoice.Card{}) {
+			p.Card = &card
+		}
+		payments = append(payments, p)
+	})
+	return payments, change, nil
+}
+
+// labelPrefix returns the value of the first label starting with prefix,
+// as the portal abbreviates some long labels differently across layouts.
+func labelPrefix(values map[string]string, prefix string) string {
+	for label, value := range values {
+		if strings.HasPrefix(label, prefix) {
+			return value
+		}
+	}
+	return ""
+}
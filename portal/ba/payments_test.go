@@ -0,0 +1,41 @@
+package ba
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/glwbr/brisa/invoice"
+)
+
+func TestParsePaymentTab(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/payment_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payments, change, err := ParsePaymentTab(page)
+	if err != nil {
+		t.Fatalf("ParsePaymentTab() error = %v", err)
+	}
+
+	want := []invoice.Payment{
+		{Method: invoice.PaymentPix, Amount: 30000},
+		{Method: invoice.PaymentCreditCard, Amount: 25000, Card: &invoice.Card{
+			Brand:         "Mastercard",
+			AcquirerCNPJ:  "01027058000191",
+			Authorization: "123456",
+		}},
+		{Method: invoice.PaymentCash, Amount: 10000},
+	}
+	if !reflect.DeepEqual(payments, want) {
+		t.Errorf("ParsePaymentTab() payments = %+v, want %+v", payments, want)
+	}
+	if change != 3090 {
+		t.Errorf("ParsePaymentTab() change = %d, want 3090", change)
+	}
+
+	if _, _, err := ParsePaymentTab([]byte("<html></html>")); !errors.Is(err, ErrPaymentTabNotFound) {
+		t.Errorf("ParsePaymentTab() of another page error = %v, want %v", err, ErrPaymentTabNotFound)
+	}
+}
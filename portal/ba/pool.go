@@ -0,0 +1,281 @@
+package ba
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"sync"
+	"time"
+
+	"github.com/glwbr/brisa/internal/http"
+	"github.com/glwbr/brisa/scraper"
+)
+
+var (
+	ErrPoolClosed      = errors.New("session pool closed")
+	ErrSessionReturned = errors.New("session already returned")
+	// ErrCheckoutOption reports an option Checkout cannot apply to a shared
+	// session, such as one that configures the connection.
+	ErrCheckoutOption = errors.New("option not allowed on checkout")
+)
+
+// PoolConfig sizes a SessionPool and sets when its sessions are replaced.
+type PoolConfig struct {
+	// Size caps the number of sessions, idle or checked out. Defaults to 4.
+	Size int
+	// MaxIdle evicts sessions left unused for longer. Zero keeps them.
+	MaxIdle time.Duration
+	// MaxAge replaces sessions created longer ago. Zero keeps them.
+	MaxAge time.Duration
+	// MaxFailures discards a session after that many consecutive failed
+	// fetches. Defaults to 3.
+	MaxFailures int
+}
+
+// PoolStats is a snapshot of a SessionPool.
+type PoolStats struct {
+	Idle      int
+	InUse     int
+	Created   int
+	Discarded int
+}
+
+// SessionPool manages independent portal sessions for concurrent fetches.
+// Each session is a Scraper with its own cookie jar and form state; all of
+// them share one connection pool.
+type SessionPool struct {
+	cfg   PoolConfig
+	opts  []Option
+	slots chan struct{}
+
+	mu        sync.Mutex
+	idle      []*Session
+	transport nethttp.RoundTripper
+	closed    bool
+	stats     PoolStats
+}
+
+// Session is a Scraper checked out of a SessionPool. It must be used by one
+// goroutine at a time and handed back with SessionPool.Return.
+type Session struct {
+	*Scraper
+
+	defaults sessionSettings
+	created  time.Time
+	lastUsed time.Time
+	failures int
+	out      bool
+}
+
+// sessionSettings are the Scraper fields a checkout may override.
+type sessionSettings struct {
+	captchaSolver scraper.CaptchaSolver
+	retryPolicy   scraper.RetryPolicy
+	observer      scraper.Observer
+}
+
+// NewSessionPool returns a pool whose sessions are built with opts.
+// Sessions are created lazily on checkout.
+func NewSessionPool(cfg PoolConfig, opts ...Option) *SessionPool {
+	if cfg.Size <= 0 {
+		cfg.Size = 4
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = 3
+	}
+	return &SessionPool{
+		cfg:   cfg,
+		opts:  opts,
+		slots: make(chan struct{}, cfg.Size),
+	}
+}
+
+// Checkout returns an idle session, or a new one if none is available and
+// the pool is not full; otherwise it waits for a session to be returned.
+// opts apply to this checkout only and may set the captcha solver, retry
+// policy and observer; options that configure the connection fail with
+// ErrCheckoutOption.
+func (p *SessionPool) Checkout(ctx context.Context, opts ...Option) (*Session, error) {
+	var settings Scraper
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	if settings.baseURL != "" || settings.rootCAs != nil || settings.insecureTLS || len(settings.httpOpts) > 0 {
+		return nil, ErrCheckoutOption
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s, err := p.take()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(s.Scraper)
+	}
+	return s, nil
+}
+
+func (p *SessionPool) take() (*Session, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	p.evictLocked(time.Now())
+	if n := len(p.idle); n > 0 {
+		s := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		s.out = true
+		p.stats.InUse++
+		p.mu.Unlock()
+		return s, nil
+	}
+	transport := p.transport
+	p.mu.Unlock()
+
+	// Building a session sets up its client, which can be slow; the slot
+	// taken by Checkout already keeps the pool within Size.
+	s, err := p.newSession(transport)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Created++
+	if p.closed {
+		p.stats.Discarded++
+		return nil, ErrPoolClosed
+	}
+	if p.transport == nil {
+		p.transport = s.client.Transport()
+	}
+	s.out = true
+	p.stats.InUse++
+	return s, nil
+}
+
+// newSession builds a session sharing transport, if set.
+func (p *SessionPool) newSession(transport nethttp.RoundTripper) (*Session, error) {
+	opts := p.opts
+	if transport != nil {
+		opts = append(opts[:len(opts):len(opts)], withHTTPOptions(http.WithTransport(transport)))
+	}
+	sc, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Session{
+		Scraper: sc,
+		defaults: sessionSettings{
+			captchaSolver: sc.captchaSolver,
+			retryPolicy:   sc.retryPolicy,
+			observer:      sc.observer,
+		},
+		created:  now,
+		lastUsed: now,
+	}, nil
+}
+
+// Return hands s back to the pool. fetchErr is the outcome of the fetch
+// made with it: sessions whose portal session expired, or that failed
+// MaxFailures times in a row, are discarded instead of reused.
+func (p *SessionPool) Return(s *Session, fetchErr error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !s.out {
+		return ErrSessionReturned
+	}
+	s.out = false
+	p.stats.InUse--
+	defer func() { <-p.slots }()
+
+	s.captchaSolver = s.defaults.captchaSolver
+	s.retryPolicy = s.defaults.retryPolicy
+	s.observer = s.defaults.observer
+
+	now := time.Now()
+	s.lastUsed = now
+	if fetchErr != nil {
+		s.failures++
+	} else {
+		s.failures = 0
+	}
+
+	if p.closed || !p.healthy(s, fetchErr, now) {
+		p.stats.Discarded++
+		return nil
+	}
+	p.idle = append(p.idle, s)
+	return nil
+}
+
+func (p *SessionPool) healthy(s *Session, fetchErr error, now time.Time) bool {
+	if s.failures >= p.cfg.MaxFailures {
+		return false
+	}
+	if fetchErr != nil && scraper.DefaultClassify(fetchErr) == scraper.RetrySession {
+		return false
+	}
+	return p.cfg.MaxAge <= 0 || now.Sub(s.created) < p.cfg.MaxAge
+}
+
+// evictLocked drops idle sessions past MaxIdle or MaxAge.
+func (p *SessionPool) evictLocked(now time.Time) {
+	kept := p.idle[:0]
+	for _, s := range p.idle {
+		idle := p.cfg.MaxIdle > 0 && now.Sub(s.lastUsed) >= p.cfg.MaxIdle
+		old := p.cfg.MaxAge > 0 && now.Sub(s.created) >= p.cfg.MaxAge
+		if idle || old {
+			p.stats.Discarded++
+			continue
+		}
+		kept = append(kept, s)
+	}
+	clear(p.idle[len(kept):])
+	p.idle = kept
+}
+
+// FetchByAccessKey checks out a session, fetches accessKey with it and
+// returns it. opts apply as in Checkout.
+func (p *SessionPool) FetchByAccessKey(ctx context.Context, accessKey string, opts ...Option) (*scraper.Result, error) {
+	s, err := p.Checkout(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.FetchByAccessKey(ctx, accessKey)
+	p.Return(s, err)
+	return result, err
+}
+
+// Stats returns the current pool counters.
+func (p *SessionPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.stats
+	st.Idle = len(p.idle)
+	return st
+}
+
+// Close discards idle sessions and fails further checkouts. Sessions
+// checked out are discarded when returned.
+func (p *SessionPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.stats.Discarded += len(p.idle)
+	p.idle = nil
+	if t, ok := p.transport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,258 @@
+package ba_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glwbr/brisa/portal/ba"
+	"github.com/glwbr/brisa/portal/ba/batest"
+	"github.com/glwbr/brisa/scraper"
+)
+
+func newPool(t *testing.T, srv *batest.Server, cfg ba.PoolConfig) *ba.SessionPool {
+	t.Helper()
+	p := ba.NewSessionPool(cfg, ba.WithBaseURL(srv.URL), ba.WithRetryPolicy(fastRetries()))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestSessionPoolConcurrentFetches(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{Size: 3})
+
+	const fetches = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, fetches)
+	for range fetches {
+		wg.Go(func() {
+			solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+			result, err := p.FetchByAccessKey(context.Background(), srv.AccessKey(), ba.WithCaptchaSolver(solver))
+			if err == nil && result.Receipt.Key != srv.AccessKey() {
+				err = errors.New("wrong receipt key " + result.Receipt.Key)
+			}
+			errs <- err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("FetchByAccessKey() error = %v", err)
+		}
+	}
+
+	st := p.Stats()
+	if st.Created > 3 || st.InUse != 0 || st.Idle != st.Created {
+		t.Errorf("Stats() = %+v, want at most 3 sessions, all idle", st)
+	}
+}
+
+func TestSessionPoolReusesSessions(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{Size: 1})
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+
+	for range 2 {
+		if _, err := p.FetchByAccessKey(context.Background(), srv.AccessKey(), ba.WithCaptchaSolver(solver)); err != nil {
+			t.Fatalf("FetchByAccessKey() error = %v", err)
+		}
+	}
+	if st := p.Stats(); st.Created != 1 || st.Idle != 1 {
+		t.Errorf("Stats() = %+v, want 1 idle session", st)
+	}
+}
+
+func TestSessionPoolEviction(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ba.PoolConfig
+	}{
+		{name: "max_idle", cfg: ba.PoolConfig{MaxIdle: time.Millisecond}},
+		{name: "max_age", cfg: ba.PoolConfig{MaxAge: time.Millisecond}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFake(t)
+			p := newPool(t, srv, tt.cfg)
+
+			s, err := p.Checkout(context.Background())
+			if err != nil {
+				t.Fatalf("Checkout() error = %v", err)
+			}
+			if err := p.Return(s, nil); err != nil {
+				t.Fatalf("Return() error = %v", err)
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			s, err = p.Checkout(context.Background())
+			if err != nil {
+				t.Fatalf("Checkout() error = %v", err)
+			}
+			p.Return(s, nil)
+			if st := p.Stats(); st.Created != 2 || st.Discarded < 1 {
+				t.Errorf("Stats() = %+v, want the first session replaced", st)
+			}
+		})
+	}
+}
+
+func TestSessionPoolDiscardsExpiredSession(t *testing.T) {
+	srv := newFake(t)
+	srv.FailNext(batest.FailSessionExpired, batest.FailSessionExpired)
+	p := newPool(t, srv, ba.PoolConfig{Size: 1})
+
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+	_, err := p.FetchByAccessKey(context.Background(), srv.AccessKey(), ba.WithCaptchaSolver(solver))
+	if !errors.Is(err, scraper.ErrSessionExpired) {
+		t.Fatalf("FetchByAccessKey() error = %v, want %v", err, scraper.ErrSessionExpired)
+	}
+	if st := p.Stats(); st.Idle != 0 || st.Discarded != 1 {
+		t.Errorf("Stats() = %+v, want the session discarded", st)
+	}
+
+	if _, err := p.FetchByAccessKey(context.Background(), srv.AccessKey(), ba.WithCaptchaSolver(solver)); err != nil {
+		t.Fatalf("FetchByAccessKey() after discard error = %v", err)
+	}
+}
+
+func TestSessionPoolCheckoutOptionsReset(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{Size: 1})
+
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+	if _, err := p.FetchByAccessKey(context.Background(), srv.AccessKey(), ba.WithCaptchaSolver(solver)); err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+	if _, err := p.FetchByAccessKey(context.Background(), srv.AccessKey()); !errors.Is(err, scraper.ErrNoCaptchaSolver) {
+		t.Errorf("FetchByAccessKey() without solver error = %v, want %v", err, scraper.ErrNoCaptchaSolver)
+	}
+}
+
+func TestSessionPoolCheckoutRejectsConnectionOptions(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{Size: 1})
+
+	for name, opt := range map[string]ba.Option{
+		"base_url":          ba.WithBaseURL("http://127.0.0.1:1"),
+		"insecure_tls":      ba.WithInsecureTLS(),
+		"transport_wrapper": ba.WithTransportWrapper(func(rt http.RoundTripper) http.RoundTripper { return rt }),
+	} {
+		if _, err := p.Checkout(context.Background(), opt); !errors.Is(err, ba.ErrCheckoutOption) {
+			t.Errorf("Checkout(%s) error = %v, want %v", name, err, ba.ErrCheckoutOption)
+		}
+	}
+
+	// The rejected checkouts took no slot nor session.
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+	if _, err := p.FetchByAccessKey(context.Background(), srv.AccessKey(), ba.WithCaptchaSolver(solver)); err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+}
+
+func TestSessionPoolBuildsSessionsUnlocked(t *testing.T) {
+	srv := newFake(t)
+	building := make(chan struct{})
+	release := make(chan struct{})
+	var started atomic.Bool
+	p := ba.NewSessionPool(ba.PoolConfig{Size: 2}, ba.WithBaseURL(srv.URL),
+		ba.WithTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			// Hold up the first session being built.
+			if started.CompareAndSwap(false, true) {
+				close(building)
+				<-release
+			}
+			return rt
+		}))
+	t.Cleanup(p.Close)
+
+	done := make(chan error, 1)
+	go func() {
+		s, err := p.Checkout(context.Background())
+		if err == nil {
+			err = p.Return(s, nil)
+		}
+		done <- err
+	}()
+	<-building
+
+	// Another checkout goes ahead while the first session is built.
+	second := make(chan error, 1)
+	go func() {
+		s, err := p.Checkout(context.Background())
+		if err == nil {
+			err = p.Return(s, nil)
+		}
+		second <- err
+	}()
+	select {
+	case err := <-second:
+		if err != nil {
+			t.Errorf("second Checkout() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Checkout() blocked while another session was built")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("first Checkout() error = %v", err)
+	}
+}
+
+func TestSessionPoolReturnTwice(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{})
+
+	s, err := p.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	if err := p.Return(s, nil); err != nil {
+		t.Fatalf("Return() error = %v", err)
+	}
+	if err := p.Return(s, nil); !errors.Is(err, ba.ErrSessionReturned) {
+		t.Errorf("second Return() error = %v, want %v", err, ba.ErrSessionReturned)
+	}
+}
+
+func TestSessionPoolCheckoutBlocksWhenFull(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{Size: 1})
+
+	s, err := p.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Checkout(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Checkout() on full pool error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	p.Return(s, nil)
+}
+
+func TestSessionPoolClose(t *testing.T) {
+	srv := newFake(t)
+	p := newPool(t, srv, ba.PoolConfig{})
+
+	s, err := p.Checkout(context.Background())
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	p.Close()
+	if _, err := p.Checkout(context.Background()); !errors.Is(err, ba.ErrPoolClosed) {
+		t.Errorf("Checkout() after Close error = %v, want %v", err, ba.ErrPoolClosed)
+	}
+	if err := p.Return(s, nil); err != nil {
+		t.Fatalf("Return() error = %v", err)
+	}
+	if st := p.Stats(); st.Idle != 0 || st.Discarded != 1 {
+		t.Errorf("Stats() = %+v, want the returned session discarded", st)
+	}
+}
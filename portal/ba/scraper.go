@@ -2,8 +2,11 @@ package ba
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"log"
+	nethttp "net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -16,8 +19,24 @@ import (
 
 type Scraper struct {
 	client        *http.Client
+	baseURL       string
 	captchaSolver scraper.CaptchaSolver
+	retryPolicy   scraper.RetryPolicy
+	observer      scraper.Observer
+	rootCAs       *x509.CertPool
+	insecureTLS   bool
+	httpOpts      []http.Option
 	formState     *scraper.FormState
+
+	// keyPageState is set while formState comes from the access key page,
+	// so that a captcha can be fetched without reloading it.
+	keyPageState bool
+	// sessionCreated and sessionUsed track the portal session for Snapshot.
+	sessionCreated time.Time
+	sessionUsed    time.Time
+
+	// attemptNo is the FetchByAccessKey attempt in progress, for events.
+	attemptNo int
 }
 
 type Option func(*Scraper)
@@ -26,43 +45,131 @@ func WithCaptchaSolver(solver scraper.CaptchaSolver) Option {
 	return func(s *Scraper) { s.captchaSolver = solver }
 }
 
+// WithRetryPolicy bounds the retries of FetchByAccessKey. It defaults to
+// scraper.DefaultRetryPolicy.
+func WithRetryPolicy(p scraper.RetryPolicy) Option {
+	return func(s *Scraper) { s.retryPolicy = p }
+}
+
+// WithObserver reports the progress of each fetch stage to o.
+func WithObserver(o scraper.Observer) Option {
+	return func(s *Scraper) { s.observer = o }
+}
+
+// WithRootCAs verifies the portal's certificate against pool. It defaults
+// to http.ICPBrasilPool, the system roots plus the ICP-Brasil CAs.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(s *Scraper) { s.rootCAs = pool }
+}
+
+// WithInsecureTLS disables certificate verification. Only use it to debug
+// a portal whose certificate chain is broken; a warning is logged.
+func WithInsecureTLS() Option {
+	return func(s *Scraper) { s.insecureTLS = true }
+}
+
+// WithTransportWrapper wraps the round tripper the scraper sends requests
+// through, e.g. to record or replay its traffic with an http.Cassette.
+func WithTransportWrapper(wrap func(nethttp.RoundTripper) nethttp.RoundTripper) Option {
+	return withHTTPOptions(http.WrapTransport(wrap))
+}
+
+// withHTTPOptions configures the underlying client after the defaults.
+func withHTTPOptions(opts ...http.Option) Option {
+	return func(s *Scraper) { s.httpOpts = append(s.httpOpts, opts...) }
+}
+
+// WithBaseURL points the scraper at another host serving the portal, such
+// as a mirror or the batest fake. It defaults to BaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(s *Scraper) { s.baseURL = baseURL }
+}
+
+// RateLimit paces the requests every Scraper in the process sends to the
+// portal host. Rate is in requests per second; zero disables pacing.
+type RateLimit struct {
+	Rate          float64
+	Burst         int
+	MaxConcurrent int
+}
+
+// DefaultRateLimit keeps batches of fetches from tripping the portal's
+// abuse protection.
+var DefaultRateLimit = RateLimit{Rate: 2, Burst: 4, MaxConcurrent: 4}
+
+// limiter is shared by all scrapers so that concurrent fetches stay polite
+// as a whole.
+var limiter = http.NewLimiter(http.LimitConfig(DefaultRateLimit))
+
+// SetRateLimit replaces the limits shared by all scrapers.
+func SetRateLimit(l RateLimit) {
+	limiter.SetConfig(http.LimitConfig(l))
+}
+
 func New(opts ...Option) (*Scraper, error) {
-	client, err := http.New(BaseURL, http.WithInsecureSkipVerify())
-	if err != nil {
-		return nil, err
-	}
-	s := &Scraper{client: client}
+	s := &Scraper{baseURL: BaseURL, retryPolicy: scraper.DefaultRetryPolicy()}
 	for _, opt := range opts {
 		opt(s)
 	}
-	return s, nil
-}
 
-func (s *Scraper) GetCaptcha(ctx context.Context) (*scraper.CaptchaChallenge, error) {
-	if err := s.loadAccessKeyPage(ctx); err != nil {
+	var tlsOpt http.Option
+	switch {
+	case s.insecureTLS:
+		log.Printf("warning: TLS certificate verification disabled for %s", s.baseURL)
+		tlsOpt = http.WithInsecureSkipVerify()
+	case s.rootCAs != nil:
+		tlsOpt = http.WithRootCAs(s.rootCAs)
+	default:
+		pool, err := http.ICPBrasilPool()
+		if err != nil {
+			return nil, fmt.Errorf("load root CAs: %w", err)
+		}
+		tlsOpt = http.WithRootCAs(pool)
+	}
+
+	client, err := http.New(s.baseURL, append([]http.Option{tlsOpt, http.WithLimiter(limiter)}, s.httpOpts...)...)
+	if err != nil {
 		return nil, err
 	}
-	return s.fetchCaptcha(ctx)
+	s.client = client
+	return s, nil
 }
 
-func (s *Scraper) SubmitWithCaptcha(ctx context.Context, accessKey, captchaSolution string) (*scraper.Result, error) {
-	accessKey = normalizeAccessKey(accessKey)
-	if !invoice.IsValidAccessKey(accessKey) {
-		return nil, scraper.ErrInvalidAccessKey
-	}
+func init() {
+	scraper.Register(invoice.PortalBA, func(cfg scraper.Config) (scraper.Fetcher, error) {
+		opts := []Option{WithCaptchaSolver(cfg.CaptchaSolver)}
+		if cfg.Observer != nil {
+			opts = append(opts, WithObserver(cfg.Observer))
+		}
+		if cfg.BaseURL != "" {
+			opts = append(opts, WithBaseURL(cfg.BaseURL))
+		}
+		if cfg.InsecureSkipVerify {
+			opts = append(opts, WithInsecureTLS())
+		}
+		if cfg.WrapTransport != nil {
+			opts = append(opts, WithTransportWrapper(cfg.WrapTransport))
+		}
+		return New(opts...)
+	}, UFCode)
+}
 
-	if s.formState == nil || !s.formState.IsValid() {
+func (s *Scraper) GetCaptcha(ctx context.Context) (*scraper.CaptchaChallenge, error) {
+	if !s.keyPageState || s.formState == nil || !s.formState.IsValid() {
 		if err := s.loadAccessKeyPage(ctx); err != nil {
 			return nil, err
 		}
 	}
+	return s.fetchCaptcha(ctx)
+}
 
-	danfeHTML, err := s.submitAccessKey(ctx, accessKey, captchaSolution)
+func (s *Scraper) SubmitWithCaptcha(ctx context.Context, accessKey, captchaSolution string) (*scraper.Result, error) {
+	key, err := parseAccessKey(accessKey)
 	if err != nil {
 		return nil, err
 	}
 
-	tabsHTML, err := s.navigateToTabs(ctx, danfeHTML)
+	danfeHTML, tabsHTML, err := s.submitAndOpenTabs(ctx, key.String(), captchaSolution)
 	if err != nil {
 		return nil, err
 	}
@@ -72,90 +179,327 @@ func (s *Scraper) SubmitWithCaptcha(ctx context.Context, accessKey, captchaSolut
 		return nil, err
 	}
 
-	receipt, err := ParseNFeTab(tabsHTML)
-	if err != nil {
-		return nil, fmt.Errorf("parse nfe tab: %w", err)
+	rawHTML := map[string][]byte{
+		"danfe":    danfeHTML,
+		"nfe_tab":  tabsHTML,
+		"products": productsHTML,
 	}
 
-	items, err := ParseProductsTab(productsHTML)
-	if err == nil {
-		receipt.Items = items
+	// The optional tabs only complete the receipt, so failing to load one
+	// is a warning. Each tab is requested from the last page loaded.
+	var warnings []error
+	current := productsHTML
+	for _, t := range optionalTabs {
+		page, err := s.loadTab(ctx, current, t.tab)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			warnings = append(warnings, fmt.Errorf("load %s tab: %w", t.tab, err))
+			continue
+		}
+		rawHTML[t.name] = page
+		current = page
+	}
+
+	var receipt *invoice.Receipt
+	err = s.step(scraper.StageParse, func() (int, error) {
+		receipt, err = ParseNFeTab(tabsHTML)
+		if err != nil {
+			return 0, fmt.Errorf("parse nfe tab: %w", err)
+		}
+		if items, err := ParseProductsTab(productsHTML); err != nil {
+			warnings = append(warnings, fmt.Errorf("parse %s tab: %w", TabProdutos, err))
+		} else {
+			receipt.Items = items
+		}
+		for _, t := range optionalTabs {
+			page, ok := rawHTML[t.name]
+			if !ok {
+				continue
+			}
+			if err := t.apply(page, receipt); err != nil {
+				warnings = append(warnings, fmt.Errorf("parse %s tab: %w", t.tab, err))
+			}
+		}
+		return 0, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &scraper.Result{
-		Receipt: receipt,
-		RawHTML: map[string][]byte{
-			"danfe":    danfeHTML,
-			"nfe_tab":  tabsHTML,
-			"products": productsHTML,
-		},
+		Receipt:  receipt,
+		RawHTML:  rawHTML,
+		Warnings: warnings,
 	}, nil
 }
 
+// optionalTabs are loaded after the products tab, in order, to complete
+// the receipt. name is the page's key in Result.RawHTML.
+var optionalTabs = []struct {
+	tab   Tab
+	name  string
+	apply func(page []byte, r *invoice.Receipt) error
+}{
+	{TabEmitente, "issuer", func(page []byte, r *invoice.Receipt) error {
+		issuer, err := ParseIssuerTab(page)
+		if err != nil {
+			return err
+		}
+		mergeIssuer(&r.Issuer, issuer)
+		return nil
+	}},
+	{TabTotais, "totals", func(page []byte, r *invoice.Receipt) error {
+		totals, err := ParseTotalsTab(page)
+		if err != nil {
+			return err
+		}
+		totals.apply(r)
+		return nil
+	}},
+	{TabCobranca, "payment", func(page []byte, r *invoice.Receipt) error {
+		payments, change, err := ParsePaymentTab(page)
+		if err != nil {
+			return err
+		}
+		r.Payments = payments
+		r.Change = change
+		return nil
+	}},
+	{TabInfAdicionais, "additional_info", func(page []byte, r *invoice.Receipt) error {
+		info, err := ParseAdditionalInfoTab(page)
+		if err != nil {
+			return err
+		}
+		r.AdditionalInfo = info
+		return nil
+	}},
+}
+
 func (s *Scraper) FetchByAccessKey(ctx context.Context, accessKey string) (*scraper.Result, error) {
-	accessKey = normalizeAccessKey(accessKey)
-	if !invoice.IsValidAccessKey(accessKey) {
-		return nil, scraper.ErrInvalidAccessKey
+	key, err := parseAccessKey(accessKey)
+	if err != nil {
+		return nil, err
 	}
 
 	if s.captchaSolver == nil {
 		return nil, scraper.ErrNoCaptchaSolver
 	}
 
-	for {
-		challenge, err := s.GetCaptcha(ctx)
+	var result *scraper.Result
+	retrier, err := s.retry(ctx, func() error {
+		captcha, err := s.solveCaptcha(ctx)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		result, err = s.SubmitWithCaptcha(ctx, key.String(), captcha)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Attempts = retrier.Attempts()
+	result.Elapsed = retrier.Elapsed()
+	return result, nil
+}
+
+// CheckStatus rechecks the status of an invoice fetched earlier, such as
+// whether it was cancelled since. It still solves a captcha and submits the
+// key, but only the NFe tab is loaded. It fails with ErrSituationNotFound
+// when the tab does not show the status.
+func (s *Scraper) CheckStatus(ctx context.Context, accessKey string) (invoice.Status, []invoice.Event, error) {
+	key, err := parseAccessKey(accessKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if s.captchaSolver == nil {
+		return "", nil, scraper.ErrNoCaptchaSolver
+	}
 
-		solution, err := s.captchaSolver.Solve(ctx, challenge)
+	var (
+		status invoice.Status
+		events []invoice.Event
+	)
+	_, err = s.retry(ctx, func() error {
+		captcha, err := s.solveCaptcha(ctx)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		_, tabsHTML, err := s.submitAndOpenTabs(ctx, key.String(), captcha)
+		if err != nil {
+			return err
+		}
+		return s.step(scraper.StageParse, func() (int, error) {
+			status, events, err = ParseNFeStatus(tabsHTML)
+			if err != nil {
+				return 0, fmt.Errorf("parse nfe tab: %w", err)
+			}
+			return 0, nil
+		})
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return status, events, nil
+}
 
-		result, err := s.SubmitWithCaptcha(ctx, accessKey, solution.Text)
-		if errors.Is(err, scraper.ErrCaptchaInvalid) {
-			fmt.Println("Invalid captcha, retrying...")
-			continue
+// retry runs attempt until it succeeds or the retry policy gives up,
+// starting a new portal session when the error calls for it.
+func (s *Scraper) retry(ctx context.Context, attempt func() error) (*scraper.Retrier, error) {
+	retrier := scraper.NewRetrier(s.retryPolicy)
+	defer func() { s.attemptNo = 0 }()
+	for {
+		retrier.Begin()
+		s.attemptNo = retrier.Attempts()
+		err := attempt()
+		if err == nil {
+			return retrier, nil
 		}
-		if err != nil {
-			return nil, err
+
+		waitStart := time.Now()
+		class, nextErr := retrier.Next(ctx, err)
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		s.emit(scraper.Event{
+			Stage:    scraper.StageRetry,
+			Phase:    scraper.PhaseFinished,
+			Duration: time.Since(waitStart),
+			Err:      err,
+		})
+		if class == scraper.RetrySession {
+			if err := s.client.ResetCookies(); err != nil {
+				return nil, err
+			}
+			s.formState = nil
+			s.keyPageState = false
+			s.sessionCreated = time.Time{}
 		}
-		return result, nil
 	}
 }
 
-func (s *Scraper) loadAccessKeyPage(ctx context.Context) error {
-	resp, err := s.client.Get(ctx, AccessKeyPage, nil)
+// solveCaptcha fetches a captcha and has the solver answer it.
+func (s *Scraper) solveCaptcha(ctx context.Context) (string, error) {
+	challenge, err := s.GetCaptcha(ctx)
 	if err != nil {
-		return err
+		return "", err
 	}
-	body, err := resp.Body()
+
+	var solution *scraper.CaptchaSolution
+	err = s.step(scraper.StageSolveCaptcha, func() (int, error) {
+		solution, err = s.captchaSolver.Solve(ctx, challenge)
+		return 0, err
+	})
 	if err != nil {
-		return err
+		return "", err
 	}
-	state, err := scraper.ParseFormState(body)
-	if err != nil {
-		return err
+	return solution.Text, nil
+}
+
+// step runs fn as the given stage, reporting its start and its outcome,
+// including the HTTP status fn returns, to the observer.
+func (s *Scraper) step(stage scraper.Stage, fn func() (status int, err error)) error {
+	start := time.Now()
+	s.emit(scraper.Event{Stage: stage, Phase: scraper.PhaseStarted, Time: start})
+	status, err := fn()
+	s.emit(scraper.Event{
+		Stage:      stage,
+		Phase:      scraper.PhaseFinished,
+		Duration:   time.Since(start),
+		StatusCode: status,
+		Err:        err,
+	})
+	if status != 0 {
+		s.sessionUsed = time.Now()
 	}
-	s.formState = state
-	return nil
+	return err
+}
+
+func (s *Scraper) emit(e scraper.Event) {
+	if s.observer == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Attempt = s.attemptNo
+	s.observer.OnEvent(e)
+}
+
+func (s *Scraper) loadAccessKeyPage(ctx context.Context) error {
+	return s.step(scraper.StageLoadPage, func() (int, error) {
+		resp, err := s.client.Get(ctx, AccessKeyPage, &http.RequestConfig{
+			ExpectMediaType: "text/html",
+			ExpectPaths:     []string{AccessKeyPage},
+		})
+		if err != nil {
+			return requestError(err)
+		}
+		body, err := resp.Body()
+		if err != nil {
+			return resp.StatusCode, err
+		}
+		state, err := scraper.ParseFormState(body)
+		if err != nil {
+			return resp.StatusCode, err
+		}
+		s.formState = state
+		s.keyPageState = true
+		if s.sessionCreated.IsZero() {
+			s.sessionCreated = time.Now()
+		}
+		return resp.StatusCode, nil
+	})
 }
 
 func (s *Scraper) fetchCaptcha(ctx context.Context) (*scraper.CaptchaChallenge, error) {
-	ts := time.Now().UnixMilli()
-	image, contentType, err := s.client.GetImage(ctx, CaptchaEndpoint, &http.RequestConfig{
-		Params:  url.Values{"t": {strconv.FormatInt(ts, 10)}},
-		Referer: s.client.BaseURL() + AccessKeyPage,
+	var challenge *scraper.CaptchaChallenge
+	err := s.step(scraper.StageFetchCaptcha, func() (int, error) {
+		ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		resp, err := s.client.Get(ctx, CaptchaEndpoint, &http.RequestConfig{
+			Params:          url.Values{"t": {ts}},
+			Referer:         s.client.BaseURL() + AccessKeyPage,
+			ExpectMediaType: "image/*",
+			ExpectPaths:     []string{CaptchaEndpoint},
+		})
+		if err != nil {
+			return requestError(err)
+		}
+		image, err := resp.RawBody()
+		if err != nil {
+			return resp.StatusCode, err
+		}
+		challenge = &scraper.CaptchaChallenge{
+			ID:          ts,
+			Image:       image,
+			ContentType: resp.Header.Get("Content-Type"),
+		}
+		return resp.StatusCode, nil
 	})
+	return challenge, err
+}
+
+// submitAndOpenTabs submits the access key and opens the tabs view, the
+// NFe tab, returning the DANFE and tabs pages.
+func (s *Scraper) submitAndOpenTabs(ctx context.Context, accessKey, captcha string) (danfeHTML, tabsHTML []byte, err error) {
+	if s.formState == nil || !s.formState.IsValid() {
+		if err := s.loadAccessKeyPage(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	danfeHTML, err = s.submitAccessKey(ctx, accessKey, captcha)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &scraper.CaptchaChallenge{
-		ID:          strconv.FormatInt(ts, 10),
-		Image:       image,
-		ContentType: contentType,
-	}, nil
+
+	tabsHTML, err = s.navigateToTabs(ctx, danfeHTML)
+	if err != nil {
+		return nil, nil, err
+	}
+	return danfeHTML, tabsHTML, nil
 }
 
 func (s *Scraper) submitAccessKey(ctx context.Context, accessKey, captcha string) ([]byte, error) {
@@ -169,26 +513,8 @@ func (s *Scraper) submitAccessKey(ctx context.Context, accessKey, captcha string
 		Set(FieldSubmit, "Consultar").
 		Build()
 
-	resp, err := s.client.PostForm(ctx, AccessKeyPage, toURLValues(form), &http.RequestConfig{
-		Referer: s.client.BaseURL() + AccessKeyPage,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	body, err := resp.Body()
-	if err != nil {
-		return nil, err
-	}
-
-	if err := checkForErrors(body); err != nil {
-		return nil, err
-	}
-
-	if state, err := scraper.ParseFormState(body); err == nil && state.IsValid() {
-		s.formState = state
-	}
-	return body, nil
+	// The portal answers errors on the key page and redirects to the DANFE.
+	return s.postBack(ctx, scraper.StageSubmitKey, AccessKeyPage, []string{AccessKeyPage, DanfePage}, form, checkForErrors)
 }
 
 func (s *Scraper) navigateToTabs(ctx context.Context, danfeHTML []byte) ([]byte, error) {
@@ -202,22 +528,7 @@ func (s *Scraper) navigateToTabs(ctx context.Context, danfeHTML []byte) ([]byte,
 		Set(FieldViewTabs, "Visualizar em Abas").
 		Build()
 
-	resp, err := s.client.PostForm(ctx, DanfePage, toURLValues(form), &http.RequestConfig{
-		Referer: s.client.BaseURL() + DanfePage,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	body, err := resp.Body()
-	if err != nil {
-		return nil, err
-	}
-
-	if state, err := scraper.ParseFormState(body); err == nil && state.IsValid() {
-		s.formState = state
-	}
-	return body, nil
+	return s.postBack(ctx, scraper.StageOpenTabs, DanfePage, []string{DanfePage, TabsPage}, form, nil)
 }
 
 func (s *Scraper) loadTab(ctx context.Context, currentHTML []byte, tab Tab) ([]byte, error) {
@@ -237,14 +548,35 @@ func (s *Scraper) loadTab(ctx context.Context, currentHTML []byte, tab Tab) ([]b
 		Set(btn+".y", "10").
 		Build()
 
-	resp, err := s.client.PostForm(ctx, TabsPage, toURLValues(form), &http.RequestConfig{
-		Referer: s.client.BaseURL() + TabsPage,
-	})
-	if err != nil {
-		return nil, err
-	}
+	return s.postBack(ctx, tab.stage(), TabsPage, []string{TabsPage}, form, nil)
+}
 
-	body, err := resp.Body()
+// postBack posts form to path as the given stage and keeps the form state
+// of the page it returns, which must be served from one of landing. check,
+// when set, turns error pages into errors.
+func (s *Scraper) postBack(ctx context.Context, stage scraper.Stage, path string, landing []string, form map[string]string, check func([]byte) error) ([]byte, error) {
+	var body []byte
+	s.keyPageState = false
+	err := s.step(stage, func() (int, error) {
+		resp, err := s.client.PostForm(ctx, path, toURLValues(form), &http.RequestConfig{
+			Referer:         s.client.BaseURL() + path,
+			ExpectMediaType: "text/html",
+			ExpectPaths:     landing,
+		})
+		if err != nil {
+			return requestError(err)
+		}
+		body, err = resp.Body()
+		if err != nil {
+			return resp.StatusCode, err
+		}
+		if check != nil {
+			if err := check(body); err != nil {
+				return resp.StatusCode, err
+			}
+		}
+		return resp.StatusCode, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -255,6 +587,23 @@ func (s *Scraper) loadTab(ctx context.Context, currentHTML []byte, tab Tab) ([]b
 	return body, nil
 }
 
+// requestError maps a failed request onto the scraper sentinels and
+// returns it with the response status, if there was one.
+func requestError(err error) (int, error) {
+	var httpErr *http.HTTPError
+	switch {
+	case errors.As(err, &httpErr):
+		if httpErr.StatusCode == nethttp.StatusForbidden {
+			// The portal refuses captchas and pages to sessions it dropped.
+			return httpErr.StatusCode, fmt.Errorf("%w: %w", scraper.ErrSessionExpired, err)
+		}
+		return httpErr.StatusCode, fmt.Errorf("%w: %w", scraper.ErrUnexpectedResponse, err)
+	case errors.Is(err, http.ErrUnexpectedContentType), errors.Is(err, http.ErrUnexpectedRedirect):
+		return 0, fmt.Errorf("%w: %w", scraper.ErrUnexpectedResponse, err)
+	}
+	return 0, err
+}
+
 func checkForErrors(html []byte) error {
 	s := strings.ToLower(string(html))
 	patterns := map[string]error{
@@ -274,14 +623,12 @@ func checkForErrors(html []byte) error {
 	return nil
 }
 
-func normalizeAccessKey(key string) string {
-	var b strings.Builder
-	for _, r := range key {
-		if r >= '0' && r <= '9' {
-			b.WriteRune(r)
-		}
+func parseAccessKey(s string) (invoice.AccessKey, error) {
+	key, err := invoice.ParseAccessKey(s)
+	if err != nil {
+		return invoice.AccessKey{}, fmt.Errorf("%w: %w", scraper.ErrInvalidAccessKey, err)
 	}
-	return b.String()
+	return key, nil
 }
 
 func toURLValues(m map[string]string) url.Values {
@@ -0,0 +1,518 @@
+package ba_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	bhttp "github.com/glwbr/brisa/internal/http"
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/portal/ba"
+	"github.com/glwbr/brisa/portal/ba/batest"
+	"github.com/glwbr/brisa/scraper"
+)
+
+// unknownKey is a valid access key the fake portal has no invoice for.
+const unknownKey = "29251106057223031484650080003212191080407665"
+
+func TestMain(m *testing.M) {
+	// The fake portal needs no politeness; TestRateLimitShared sets its own.
+	ba.SetRateLimit(ba.RateLimit{})
+	os.Exit(m.Run())
+}
+
+func newFake(t *testing.T) *batest.Server {
+	t.Helper()
+	srv, err := batest.NewServer(os.DirFS("../../testdata"))
+	if err != nil {
+		t.Fatalf("batest.NewServer() error = %v", err)
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// answerSolver answers each challenge with the next answer, repeating the
+// last one, and counts the challenges it sees.
+type answerSolver struct {
+	answers []string
+	calls   int
+}
+
+func (s *answerSolver) Solve(_ context.Context, c *scraper.CaptchaChallenge) (*scraper.CaptchaSolution, error) {
+	answer := s.answers[min(s.calls, len(s.answers)-1)]
+	s.calls++
+	return &scraper.CaptchaSolution{Text: answer, ChallengeID: c.ID}, nil
+}
+
+// fastRetries is the default policy without waits between attempts.
+func fastRetries() scraper.RetryPolicy {
+	p := scraper.DefaultRetryPolicy()
+	p.BaseDelay = 0
+	return p
+}
+
+func TestFetchByAccessKey(t *testing.T) {
+	srv := newFake(t)
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+	s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(solver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.FetchByAccessKey(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+
+	r := result.Receipt
+	if r.Key != srv.AccessKey() {
+		t.Errorf("Key = %q, want %q", r.Key, srv.AccessKey())
+	}
+	if r.Total != 61910 || r.Subtotal != 62574 || r.Discount != 664 {
+		t.Errorf("Subtotal/Discount/Total = %d/%d/%d, want 62574/664/61910", r.Subtotal, r.Discount, r.Total)
+	}
+	if r.TaxTotals == nil || r.TaxTotals.ICMS != 7421 {
+		t.Errorf("TaxTotals = %+v, want ICMS 7421", r.TaxTotals)
+	}
+	if len(r.Payments) != 3 || r.Change != 3090 {
+		t.Errorf("Payments = %+v, Change = %d, want 3 payments and 3090", r.Payments, r.Change)
+	}
+	if a := r.Authorization; a == nil || a.Protocol != "229250321773309" {
+		t.Errorf("Authorization = %+v, want protocol 229250321773309", a)
+	}
+	if !strings.HasPrefix(r.AdditionalInfo, "Documento emitido por ME ou EPP") {
+		t.Errorf("AdditionalInfo = %q, want the Informações Adicionais tab's notes", r.AdditionalInfo)
+	}
+	if findings := invoice.Validate(r); len(findings) != 0 {
+		t.Errorf("Validate() = %v", findings)
+	}
+	if len(r.Items) != 29 {
+		t.Errorf("len(Items) = %d, want 29", len(r.Items))
+	}
+	if r.Issuer.TradeName != "ASSAI ATACADISTA" || r.Issuer.Address.CityCode != "2927408" {
+		t.Errorf("Issuer = %+v, want the Emitente tab's data", r.Issuer)
+	}
+	for _, page := range []string{"danfe", "nfe_tab", "products", "issuer", "totals", "payment"} {
+		if len(result.RawHTML[page]) == 0 {
+			t.Errorf("RawHTML[%q] is empty", page)
+		}
+	}
+	if solver.calls != 1 || result.Attempts != 1 {
+		t.Errorf("solver called %d times, Attempts = %d, want 1", solver.calls, result.Attempts)
+	}
+	if result.Elapsed <= 0 {
+		t.Errorf("Elapsed = %v", result.Elapsed)
+	}
+}
+
+func TestFetchOptionalTabFailures(t *testing.T) {
+	srv := newFake(t)
+	srv.FailTab(ba.TabTotais, batest.FailException)
+	srv.FailTab(ba.TabCobranca, batest.FailLayoutChanged)
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+	s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(solver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.FetchByAccessKey(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+
+	r := result.Receipt
+	if len(r.Items) != 29 || r.Issuer.TradeName == "" || r.AdditionalInfo == "" {
+		t.Errorf("receipt lacks the tabs that loaded: %+v", r)
+	}
+	if r.TaxTotals != nil || len(r.Payments) != 0 {
+		t.Errorf("TaxTotals = %+v, Payments = %+v, want none", r.TaxTotals, r.Payments)
+	}
+	if len(result.Warnings) != 2 ||
+		!errors.Is(result.Warnings[0], scraper.ErrUnexpectedResponse) ||
+		!errors.Is(result.Warnings[1], ba.ErrPaymentTabNotFound) {
+		t.Errorf("Warnings = %v, want the totais load and cobranca parse failures", result.Warnings)
+	}
+	if _, ok := result.RawHTML["totals"]; ok {
+		t.Errorf("RawHTML has the totals tab that failed to load")
+	}
+}
+
+func TestCheckStatus(t *testing.T) {
+	srv := newFake(t)
+	solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+	s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(solver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, events, err := s.CheckStatus(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("CheckStatus() error = %v", err)
+	}
+	if status != invoice.StatusAuthorized || len(events) != 0 {
+		t.Errorf("CheckStatus() = %q, %v, want authorized without events", status, events)
+	}
+	if n := srv.Requests(ba.TabsPage); n != 1 {
+		t.Errorf("tabs page requests = %d, want 1: only the NFe tab is needed", n)
+	}
+
+	srv.Cancel()
+	status, events, err = s.CheckStatus(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("CheckStatus() after cancellation error = %v", err)
+	}
+	if status != invoice.StatusCancelled || len(events) != 1 || events[0].Type != invoice.EventCancellation {
+		t.Errorf("CheckStatus() = %q, %+v, want cancelled with a cancellation event", status, events)
+	}
+}
+
+func TestFetchByAccessKeyRetriesWrongCaptcha(t *testing.T) {
+	srv := newFake(t)
+	srv.FailNext(batest.FailWrongCaptcha)
+	solver := &answerSolver{answers: []string{"WRONG", batest.CaptchaAnswer}}
+	s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(solver), ba.WithRetryPolicy(fastRetries()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.FetchByAccessKey(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+	if solver.calls != 2 || result.Attempts != 2 {
+		t.Errorf("solver called %d times, Attempts = %d, want 2", solver.calls, result.Attempts)
+	}
+	if got := srv.Requests(ba.CaptchaEndpoint); got != 2 {
+		t.Errorf("captcha fetched %d times, want 2", got)
+	}
+}
+
+func TestFetchByAccessKeyRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  []batest.Failure
+		key       string
+		want      error
+		exhausted bool
+		attempts  int
+	}{
+		{name: "session_reloaded", failures: []batest.Failure{batest.FailSessionExpired}, attempts: 2},
+		{name: "exception_reloaded", failures: []batest.Failure{batest.FailException}, attempts: 2},
+		{
+			name:      "captcha_exhausted",
+			failures:  []batest.Failure{batest.FailWrongCaptcha, batest.FailWrongCaptcha, batest.FailWrongCaptcha},
+			want:      scraper.ErrCaptchaInvalid,
+			exhausted: true,
+			attempts:  3,
+		},
+		{
+			name:      "session_exhausted",
+			failures:  []batest.Failure{batest.FailSessionExpired, batest.FailSessionExpired},
+			want:      scraper.ErrSessionExpired,
+			exhausted: true,
+			attempts:  2,
+		},
+		{name: "not_found", failures: []batest.Failure{batest.FailNotFound}, want: scraper.ErrInvoiceNotFound, attempts: 1},
+		{name: "unknown_key", key: unknownKey, want: scraper.ErrInvoiceNotFound, attempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFake(t)
+			srv.FailNext(tt.failures...)
+			key := tt.key
+			if key == "" {
+				key = srv.AccessKey()
+			}
+
+			solver := &answerSolver{answers: []string{batest.CaptchaAnswer}}
+			s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(solver), ba.WithRetryPolicy(fastRetries()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := s.FetchByAccessKey(context.Background(), key)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("FetchByAccessKey() error = %v, want %v", err, tt.want)
+			}
+			if got := errors.Is(err, scraper.ErrRetriesExhausted); got != tt.exhausted {
+				t.Errorf("errors.Is(err, ErrRetriesExhausted) = %v, want %v", got, tt.exhausted)
+			}
+			if solver.calls != tt.attempts {
+				t.Errorf("solver called %d times, want %d", solver.calls, tt.attempts)
+			}
+			if err == nil && result.Attempts != tt.attempts {
+				t.Errorf("Attempts = %d, want %d", result.Attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestSubmitAfterSessionExpired(t *testing.T) {
+	srv := newFake(t)
+	s, err := ba.New(ba.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := s.GetCaptcha(context.Background())
+	if err != nil {
+		t.Fatalf("GetCaptcha() error = %v", err)
+	}
+	if challenge.ContentType != "image/png" || len(challenge.Image) == 0 {
+		t.Errorf("challenge = %q with %d bytes", challenge.ContentType, len(challenge.Image))
+	}
+
+	srv.ExpireSessions()
+	if _, err := s.SubmitWithCaptcha(context.Background(), srv.AccessKey(), batest.CaptchaAnswer); !errors.Is(err, scraper.ErrSessionExpired) {
+		t.Errorf("SubmitWithCaptcha() error = %v, want %v", err, scraper.ErrSessionExpired)
+	}
+}
+
+func TestFetchViaRegistry(t *testing.T) {
+	srv := newFake(t)
+	cfg := scraper.Config{
+		CaptchaSolver: &answerSolver{answers: []string{batest.CaptchaAnswer}},
+		BaseURL:       srv.URL,
+	}
+	result, err := scraper.Fetch(context.Background(), srv.AccessKey(), cfg)
+	if err != nil {
+		t.Fatalf("scraper.Fetch() error = %v", err)
+	}
+	if result.Receipt.Key != srv.AccessKey() {
+		t.Errorf("Key = %q", result.Receipt.Key)
+	}
+}
+
+func TestObserverEvents(t *testing.T) {
+	srv := newFake(t)
+	srv.FailNext(batest.FailWrongCaptcha)
+
+	var events []scraper.Event
+	s, err := ba.New(
+		ba.WithBaseURL(srv.URL),
+		ba.WithCaptchaSolver(&answerSolver{answers: []string{batest.CaptchaAnswer}}),
+		ba.WithRetryPolicy(fastRetries()),
+		ba.WithObserver(scraper.ObserverFunc(func(e scraper.Event) { events = append(events, e) })),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.FetchByAccessKey(context.Background(), srv.AccessKey()); err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+
+	var finished []scraper.Stage
+	for _, e := range events {
+		if e.Phase != scraper.PhaseFinished {
+			continue
+		}
+		finished = append(finished, e.Stage)
+		switch e.Stage {
+		case scraper.StageSubmitKey:
+			if e.Attempt == 1 && !errors.Is(e.Err, scraper.ErrCaptchaInvalid) {
+				t.Errorf("first submit_key error = %v, want %v", e.Err, scraper.ErrCaptchaInvalid)
+			}
+			fallthrough
+		case scraper.StageLoadPage, scraper.StageFetchCaptcha, scraper.StageOpenTabs, scraper.StageLoadProducts:
+			if e.StatusCode != 200 {
+				t.Errorf("%s status = %d, want 200", e.Stage, e.StatusCode)
+			}
+		}
+	}
+
+	want := []scraper.Stage{
+		scraper.StageLoadPage, scraper.StageFetchCaptcha, scraper.StageSolveCaptcha, scraper.StageSubmitKey,
+		scraper.StageRetry,
+		scraper.StageLoadPage, scraper.StageFetchCaptcha, scraper.StageSolveCaptcha, scraper.StageSubmitKey,
+		scraper.StageOpenTabs, scraper.StageLoadProducts, "load_tab_emitente", "load_tab_totais", "load_tab_cobranca", "load_tab_infadicionais",
+		scraper.StageParse,
+	}
+	if !slices.Equal(finished, want) {
+		t.Errorf("finished stages = %v, want %v", finished, want)
+	}
+	if last := events[len(events)-1]; last.Attempt != 2 || last.Err != nil {
+		t.Errorf("last event = %+v, want attempt 2 without error", last)
+	}
+}
+
+func TestRateLimitShared(t *testing.T) {
+	ba.SetRateLimit(ba.RateLimit{Rate: 20, Burst: 1})
+	t.Cleanup(func() { ba.SetRateLimit(ba.RateLimit{}) })
+
+	srv := newFake(t)
+	start := time.Now()
+	for range 2 {
+		// Each scraper makes two requests: the access key page and the captcha.
+		s, err := ba.New(ba.WithBaseURL(srv.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetCaptcha(context.Background()); err != nil {
+			t.Fatalf("GetCaptcha() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 requests at 20/s took %v, want at least 150ms", elapsed)
+	}
+}
+
+func TestTLSVerification(t *testing.T) {
+	srv, err := batest.NewTLSServer(os.DirFS("../../testdata"))
+	if err != nil {
+		t.Fatalf("batest.NewTLSServer() error = %v", err)
+	}
+	t.Cleanup(srv.Close)
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(srv.Certificate())
+
+	tests := []struct {
+		name    string
+		opts    []ba.Option
+		wantErr bool
+	}{
+		{name: "default_roots", wantErr: true},
+		{name: "custom_ca", opts: []ba.Option{ba.WithRootCAs(trusted)}},
+		{name: "other_ca", opts: []ba.Option{ba.WithRootCAs(x509.NewCertPool())}, wantErr: true},
+		{name: "insecure", opts: []ba.Option{ba.WithInsecureTLS()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ba.New(append([]ba.Option{ba.WithBaseURL(srv.URL)}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.GetCaptcha(context.Background())
+			var certErr *tls.CertificateVerificationError
+			if got := errors.As(err, &certErr); got != tt.wantErr {
+				t.Errorf("GetCaptcha() error = %v, want certificate error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("GetCaptcha() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	const page = `<form method="post"><input type="hidden" name="__VIEWSTATE" value="vs"></form>`
+	tests := []struct {
+		name    string
+		keyPage http.HandlerFunc
+		captcha http.HandlerFunc
+		want    error
+	}{
+		{
+			// The portal's error page is an ordinary ASP.NET form served as 200.
+			name: "redirect_to_error_page",
+			keyPage: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/servicos/nfce/Erro.aspx", http.StatusFound)
+			},
+			want: scraper.ErrUnexpectedResponse,
+		},
+		{
+			name: "server_error",
+			captcha: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Ocorreu um erro", http.StatusInternalServerError)
+			},
+			want: scraper.ErrUnexpectedResponse,
+		},
+		{
+			name:    "forbidden",
+			captcha: func(w http.ResponseWriter, r *http.Request) { http.Error(w, "no session", http.StatusForbidden) },
+			want:    scraper.ErrSessionExpired,
+		},
+		{
+			name: "html_captcha",
+			captcha: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(page))
+			},
+			want: scraper.ErrUnexpectedResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servePage := func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(page))
+			}
+			keyPage := tt.keyPage
+			if keyPage == nil {
+				keyPage = servePage
+			}
+			mux := http.NewServeMux()
+			mux.HandleFunc(ba.AccessKeyPage, keyPage)
+			mux.HandleFunc("/servicos/nfce/Erro.aspx", servePage)
+			if tt.captcha != nil {
+				mux.HandleFunc(ba.CaptchaEndpoint, tt.captcha)
+			}
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			s, err := ba.New(ba.WithBaseURL(srv.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.GetCaptcha(context.Background()); !errors.Is(err, tt.want) {
+				t.Errorf("GetCaptcha() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchReplay(t *testing.T) {
+	srv := newFake(t)
+	srv.FailNext(batest.FailWrongCaptcha)
+
+	cassette := &bhttp.Cassette{}
+	s, err := ba.New(
+		ba.WithBaseURL(srv.URL),
+		ba.WithCaptchaSolver(&answerSolver{answers: []string{"WRONG", batest.CaptchaAnswer}}),
+		ba.WithRetryPolicy(fastRetries()),
+		ba.WithTransportWrapper(cassette.Recorder),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := s.FetchByAccessKey(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("recording FetchByAccessKey() error = %v", err)
+	}
+	srv.Close()
+
+	// The replay needs neither the portal nor the right captcha answer.
+	s, err = ba.New(
+		ba.WithBaseURL(srv.URL),
+		ba.WithCaptchaSolver(&answerSolver{answers: []string{"ANY"}}),
+		ba.WithRetryPolicy(fastRetries()),
+		ba.WithTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+			return cassette.Replayer(ba.FieldAccessKey)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := s.FetchByAccessKey(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("replayed FetchByAccessKey() error = %v", err)
+	}
+	if replayed.Attempts != 2 {
+		t.Errorf("replayed Attempts = %d, want 2", replayed.Attempts)
+	}
+	if !reflect.DeepEqual(replayed.Receipt, recorded.Receipt) {
+		t.Errorf("replayed receipt differs from the recorded one")
+	}
+}
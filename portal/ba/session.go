@@ -0,0 +1,64 @@
+package ba
+
+import (
+	"fmt"
+	nethttp "net/http"
+	"time"
+
+	"github.com/glwbr/brisa/scraper"
+)
+
+// SessionTimeout is how long the portal keeps an idle session, the ASP.NET
+// default.
+const SessionTimeout = 20 * time.Minute
+
+// Snapshot returns the scraper's portal session, or nil before the first
+// page load. It implements scraper.Resumable.
+func (s *Scraper) Snapshot() *scraper.SessionSnapshot {
+	if s.sessionCreated.IsZero() {
+		return nil
+	}
+	snap := &scraper.SessionSnapshot{
+		BaseURL:   s.baseURL,
+		Cookies:   map[string]string{},
+		CreatedAt: s.sessionCreated,
+		ExpiresAt: s.sessionUsed.Add(SessionTimeout),
+	}
+	for _, c := range s.client.Cookies() {
+		snap.Cookies[c.Name] = c.Value
+	}
+	// Only the access key page's state can start a fetch.
+	if s.keyPageState && s.formState != nil {
+		state := *s.formState
+		snap.FormState = &state
+	}
+	return snap
+}
+
+// Restore resumes a session taken by Snapshot, replacing the current one.
+// If the portal dropped it meanwhile, the next fetch gets
+// scraper.ErrSessionExpired and starts a new session as usual.
+func (s *Scraper) Restore(snap *scraper.SessionSnapshot) error {
+	if snap.BaseURL != s.baseURL {
+		return fmt.Errorf("%w: %s", scraper.ErrSnapshotMismatch, snap.BaseURL)
+	}
+	if err := s.client.ResetCookies(); err != nil {
+		return err
+	}
+	cookies := make([]*nethttp.Cookie, 0, len(snap.Cookies))
+	for name, value := range snap.Cookies {
+		cookies = append(cookies, &nethttp.Cookie{Name: name, Value: value, Path: "/"})
+	}
+	if err := s.client.SetCookies(cookies); err != nil {
+		return err
+	}
+
+	s.formState, s.keyPageState = nil, false
+	if snap.FormState != nil {
+		state := *snap.FormState
+		s.formState, s.keyPageState = &state, true
+	}
+	s.sessionCreated = snap.CreatedAt
+	s.sessionUsed = snap.ExpiresAt.Add(-SessionTimeout)
+	return nil
+}
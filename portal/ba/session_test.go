@@ -0,0 +1,104 @@
+package ba_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glwbr/brisa/portal/ba"
+	"github.com/glwbr/brisa/portal/ba/batest"
+	"github.com/glwbr/brisa/scraper"
+)
+
+// snapshotAfterCaptcha opens a session on srv and returns its snapshot, as
+// saved to disk and read back.
+func snapshotAfterCaptcha(t *testing.T, srv *batest.Server) *scraper.SessionSnapshot {
+	t.Helper()
+	s, err := ba.New(ba.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Snapshot() != nil {
+		t.Error("Snapshot() before any request is not nil")
+	}
+	if _, err := s.GetCaptcha(context.Background()); err != nil {
+		t.Fatalf("GetCaptcha() error = %v", err)
+	}
+
+	data, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap scraper.SessionSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatal(err)
+	}
+	return &snap
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	srv := newFake(t)
+	snap := snapshotAfterCaptcha(t, srv)
+
+	if len(snap.Cookies) == 0 || snap.FormState == nil || !snap.FormState.IsValid() {
+		t.Fatalf("snapshot = %+v, want cookies and form state", snap)
+	}
+	if snap.Expired(time.Now()) || !snap.Expired(time.Now().Add(ba.SessionTimeout)) {
+		t.Errorf("ExpiresAt = %v, want about %v from now", snap.ExpiresAt, ba.SessionTimeout)
+	}
+
+	s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(&answerSolver{answers: []string{batest.CaptchaAnswer}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore(snap); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if _, err := s.FetchByAccessKey(context.Background(), srv.AccessKey()); err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+	// One load by the first scraper and the access key submission.
+	if got := srv.Requests(ba.AccessKeyPage); got != 2 {
+		t.Errorf("access key page requested %d times, want 2", got)
+	}
+}
+
+func TestRestoreExpiredSession(t *testing.T) {
+	srv := newFake(t)
+	snap := snapshotAfterCaptcha(t, srv)
+	srv.ExpireSessions()
+
+	s, err := ba.New(ba.WithBaseURL(srv.URL), ba.WithCaptchaSolver(&answerSolver{answers: []string{batest.CaptchaAnswer}}), ba.WithRetryPolicy(fastRetries()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore(snap); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	result, err := s.FetchByAccessKey(context.Background(), srv.AccessKey())
+	if err != nil {
+		t.Fatalf("FetchByAccessKey() error = %v", err)
+	}
+	if result.Attempts != 2 {
+		t.Errorf("Attempts = %d, want 2", result.Attempts)
+	}
+	if got := s.Snapshot(); got == nil || got.CreatedAt.Equal(snap.CreatedAt) {
+		t.Errorf("Snapshot() = %+v, want a new session", got)
+	}
+}
+
+func TestRestoreOtherPortal(t *testing.T) {
+	srv := newFake(t)
+	snap := snapshotAfterCaptcha(t, srv)
+	snap.BaseURL = "https://example.com"
+
+	s, err := ba.New(ba.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Restore(snap); !errors.Is(err, scraper.ErrSnapshotMismatch) {
+		t.Errorf("Restore() error = %v, want %v", err, scraper.ErrSnapshotMismatch)
+	}
+}
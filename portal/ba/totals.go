@@ -0,0 +1,76 @@
+package ba
+
+import (
+	"errors"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
+	"github.com/glwbr/brisa/scraper"
+	"golang.org/x/net/html"
+)
+
+var ErrTotalsTabNotFound = errors.New("totals tab not found")
+
+// Totals holds the values of the Totais tab, the NF-e ICMSTot group.
+type Totals struct {
+	Subtotal   money.BRL // vProd
+	Discount   money.BRL // vDesc
+	Freight    money.BRL // vFrete
+	Insurance  money.BRL // vSeg
+	OtherCosts money.BRL // vOutro
+	Total      money.BRL // vNF
+	// TaxAmount is the approximate tax burden, vTotTrib.
+	TaxAmount money.BRL
+	Taxes     invoice.TaxTotals
+}
+
+// ParseTotalsTab parses the Totais tab.
+func ParseTotalsTab(htmlBytes []byte) (*Totals, error) {
+	doc, err := scraper.ParseHTML(htmlBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	totais := doc.Find("#Totais")
+	if totais.Length() == 0 {
+		return nil, ErrTotalsTabNotFound
+	}
+
+	v := scraper.CollectLabelValues(totais, map[*html.Node]string{})
+	return &Totals{
+		Subtotal:   parseMoneyOrZero(v["Valor Total dos Produtos"]),
+		Discount:   parseMoneyOrZero(v["Valor Total dos Descontos"]),
+		Freight:    parseMoneyOrZero(v["Valor do Frete"]),
+		Insurance:  parseMoneyOrZero(v["Valor do Seguro"]),
+		OtherCosts: parseMoneyOrZero(v["Outras Despesas Acessórias"]),
+		Total:      parseMoneyOrZero(v["Valor Total da NFC-e"]),
+		TaxAmount:  parseMoneyOrZero(v["Valor Aproximado dos Tributos"]),
+		Taxes: invoice.TaxTotals{
+			ICMSBase:  parseMoneyOrZero(v["Base de Cálculo ICMS"]),
+			ICMS:      parseMoneyOrZero(v["Valor do ICMS"]),
+			ICMSST:    parseMoneyOrZero(v["Valor ICMS Substituição"]),
+			IPI:       parseMoneyOrZero(v["Valor Total do IPI"]),
+			PIS:       parseMoneyOrZero(v["Valor do PIS"]),
+			COFINS:    parseMoneyOrZero(v["Valor da COFINS"]),
+			ImportTax: parseMoneyOrZero(v["Valor Total do II"]),
+		},
+	}, nil
+}
+
+// apply sets the receipt's totals. The NFe tab's subtotal and total are
+// kept if the tab shows none.
+func (t *Totals) apply(r *invoice.Receipt) {
+	if t.Subtotal != 0 {
+		r.Subtotal = t.Subtotal
+	}
+	r.Discount = t.Discount
+	r.Freight = t.Freight
+	r.Insurance = t.Insurance
+	r.OtherCosts = t.OtherCosts
+	if t.Total != 0 {
+		r.Total = t.Total
+	}
+	r.Taxes.Amount = t.TaxAmount
+	taxes := t.Taxes
+	r.TaxTotals = &taxes
+}
@@ -0,0 +1,63 @@
+package ba
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/glwbr/brisa/invoice"
+)
+
+func TestParseTotalsTab(t *testing.T) {
+	page, err := os.ReadFile("../../testdata/totals_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals, err := ParseTotalsTab(page)
+	if err != nil {
+		t.Fatalf("ParseTotalsTab() error = %v", err)
+	}
+
+	want := Totals{
+		Subtotal:  62574,
+		Discount:  664,
+		Total:     61910,
+		TaxAmount: 9836,
+		Taxes:     invoice.TaxTotals{ICMSBase: 41230, ICMS: 7421, PIS: 512, COFINS: 2358},
+	}
+	if *totals != want {
+		t.Errorf("ParseTotalsTab() = %+v, want %+v", *totals, want)
+	}
+
+	r := &invoice.Receipt{Subtotal: 61910, Total: 61910}
+	totals.apply(r)
+	if r.Subtotal != want.Subtotal || r.Discount != want.Discount || r.Taxes.Amount != want.TaxAmount ||
+		r.TaxTotals == nil || *r.TaxTotals != want.Taxes {
+		t.Errorf("apply() receipt = %+v", r)
+	}
+
+	if _, err := ParseTotalsTab([]byte("<html></html>")); !errors.Is(err, ErrTotalsTabNotFound) {
+		t.Errorf("ParseTotalsTab() of another page error = %v, want %v", err, ErrTotalsTabNotFound)
+	}
+}
+
+func TestApplyTotalsMissingLabels(t *testing.T) {
+	page := []byte(`<html><body><div id="Totais"><table><tr><td>` +
+		`<label>Valor dos Produtos</label><span class="linha">625,74</span>` +
+		`</td></tr></table></div></body></html>`)
+	totals, err := ParseTotalsTab(page)
+	if err != nil {
+		t.Fatalf("ParseTotalsTab() error = %v", err)
+	}
+
+	r := &invoice.Receipt{Subtotal: 61910, Total: 61910}
+	totals.apply(r)
+	if r.Subtotal != 61910 || r.Total != 61910 {
+		t.Errorf("apply() Subtotal/Total = %d/%d, want the NFe tab's 61910", r.Subtotal, r.Total)
+	}
+	for _, f := range invoice.Validate(r) {
+		if f.Rule == invoice.RuleTotal {
+			t.Errorf("Validate() = %v", f)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
+)
+
+// Namespace is the XML namespace of the NF-e/NFC-e layout.
+const Namespace = "http://www.portalfiscal.inf.br/nfe"
+
+// ErrNoIssueDate reports a receipt that cannot be encoded for lack of the
+// issue date, which dhEmi requires.
+var ErrNoIssueDate = errors.New("receipt has no issue date")
+
+// ReconstructedVerProc is written to ide/verProc of encoded documents. Along
+// with the leading comment and the missing signature and protocol, it marks
+// them as rebuilt from a Receipt rather than issued by the taxpayer.
+const ReconstructedVerProc = "brisa-reconstructed"
+
+const reconstructedNotice = " Reconstructed by brisa from public portal data. " +
+	"Unsigned and not authorized by SEFAZ; it has no fiscal validity. "
+
+// Encode serializes r as an unsigned NFe/infNFe document (layout 4.00) of
+// the model in its access key. Fields the portal does not expose, such as
+// the issuer's tax regime when unknown, are filled with layout defaults and
+// per-item tax values are recomputed from the rates.
+func Encode(r *invoice.Receipt) ([]byte, error) {
+	key, err := invoice.ParseAccessKey(r.Key)
+	if err != nil {
+		return nil, fmt.Errorf("access key: %w", err)
+	}
+	if r.IssueDate.IsZero() {
+		return nil, ErrNoIssueDate
+	}
+
+	doc := fromReceipt(r, key)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<!--" + reconstructedNotice + "-->\n")
+	if err := xml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, fmt.Errorf("encode NFe: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func fromReceipt(r *invoice.Receipt, key invoice.AccessKey) *nfe {
+	inf := infNFe{
+		ID:      "NFe" + key.String(),
+		Version: "4.00",
+		Ide: ide{
+			CUF:      strconv.Itoa(key.UF),
+			CNF:      key.Code,
+			NatOp:    "VENDA",
+			Mod:      strconv.Itoa(int(key.Model)),
+			Serie:    strconv.Itoa(key.Series),
+			NNF:      strconv.Itoa(key.Number),
+			DhEmi:    r.IssueDate.Format("2006-01-02T15:04:05-07:00"),
+			TpNF:     "1",
+			IDDest:   "1",
+			TpImp:    "4",
+			TpEmis:   strconv.Itoa(key.EmissionType),
+			CDV:      strconv.Itoa(key.CheckDigit),
+			TpAmb:    "1",
+			FinNFe:   "1",
+			IndFinal: "1",
+			IndPres:  "1",
+			ProcEmi:  "0",
+			VerProc:  ReconstructedVerProc,
+		},
+		Emit: emit{
+			XNome: r.Issuer.Name,
+			XFant: r.Issuer.TradeName,
+			EnderEmit: ender{
+				XLgr:    r.Issuer.Address.Street,
+				Nro:     r.Issuer.Address.Number,
+				XCpl:    r.Issuer.Address.Complement,
+				XBairro: r.Issuer.Address.District,
+				CMun:    r.Issuer.Address.CityCode,
+				XMun:    r.Issuer.Address.City,
+				UF:      r.Issuer.Address.State,
+				CEP:     r.Issuer.Address.ZipCode,
+				Fone:    r.Issuer.Phone,
+			},
+			IE:  r.Issuer.StateRegID,
+			IM:  r.Issuer.MunicipalID,
+			CRT: r.Issuer.TaxRegime.Code(),
+		},
+		Transp: &transp{ModFrete: "9"},
+	}
+	if len(r.Issuer.CNPJ) == 11 {
+		inf.Emit.CPF = r.Issuer.CNPJ
+	} else {
+		inf.Emit.CNPJ = r.Issuer.CNPJ
+	}
+	if a := r.Authorization; a != nil && a.Environment != 0 {
+		inf.Ide.TpAmb = strconv.Itoa(int(a.Environment))
+	}
+	if inf.Emit.CRT == "" {
+		inf.Emit.CRT = invoice.TaxRegimeNormal.Code()
+	}
+	if inf.Emit.EnderEmit.UF == "" {
+		inf.Emit.EnderEmit.UF = key.State()
+	}
+
+	if doc := r.Consumer.Document; doc != "" {
+		d := &dest{XNome: r.Consumer.Name, IndIEDest: "9"}
+		switch len(doc) {
+		case 11:
+			d.CPF = doc
+		case 14:
+			d.CNPJ = doc
+		default:
+			d.IDEstrangeiro = doc
+		}
+		inf.Dest = d
+	}
+
+	var vBC, vICMS, vPIS, vCOFINS money.BRL
+	for i, item := range r.Items {
+		n := item.LineNumber
+		if n <= 0 {
+			n = i + 1
+		}
+		d, t := fromItem(item, n, r.Issuer.TaxRegime)
+		inf.Det = append(inf.Det, d)
+		vBC = vBC.Add(t.base)
+		vICMS = vICMS.Add(t.icms)
+		vPIS = vPIS.Add(t.pis)
+		vCOFINS = vCOFINS.Add(t.cofins)
+	}
+
+	var vST, vIPI, vII money.BRL
+	if tt := r.TaxTotals; tt != nil {
+		vICMS, vST, vIPI, vII, vPIS, vCOFINS = tt.ICMS, tt.ICMSST, tt.IPI, tt.ImportTax, tt.PIS, tt.COFINS
+		if tt.ICMSBase != 0 {
+			vBC = tt.ICMSBase
+		}
+	}
+	zero := money.BRL(0).Decimal(2)
+	inf.Total.ICMSTot = icmsTot{
+		VBC:        vBC.Decimal(2),
+		VICMS:      vICMS.Decimal(2),
+		VICMSDeson: zero,
+		VFCP:       zero,
+		VBCST:      zero,
+		VST:        vST.Decimal(2),
+		VFCPST:     zero,
+		VFCPSTRet:  zero,
+		VProd:      r.Subtotal.Decimal(2),
+		VFrete:     r.Freight.Decimal(2),
+		VSeg:       r.Insurance.Decimal(2),
+		VDesc:      r.Discount.Decimal(2),
+		VII:        vII.Decimal(2),
+		VIPI:       vIPI.Decimal(2),
+		VIPIDevol:  zero,
+		VPIS:       vPIS.Decimal(2),
+		VCOFINS:    vCOFINS.Decimal(2),
+		VOutro:     r.OtherCosts.Decimal(2),
+		VNF:        r.Total.Decimal(2),
+	}
+	if r.Taxes.Amount != 0 {
+		inf.Total.ICMSTot.VTotTrib = r.Taxes.Amount.Decimal(2)
+	}
+
+	inf.Pag = fromPayments(r.Payments, r.Total)
+	if r.AdditionalInfo != "" {
+		inf.InfAdic = &infAdic{InfCpl: r.AdditionalInfo}
+	}
+
+	return &nfe{
+		XMLName: xml.Name{Space: Namespace, Local: "NFe"},
+		InfNFe:  inf,
+	}
+}
+
+// itemTaxes holds the values recomputed for one item, summed into ICMSTot
+// when the receipt carries no TaxTotals.
+type itemTaxes struct {
+	base, icms, pis, cofins money.BRL
+}
+
+func fromItem(item invoice.Item, n int, regime invoice.TaxRegime) (det, itemTaxes) {
+	gtin := item.GTIN
+	if gtin == "" {
+		gtin = "SEM GTIN"
+	}
+	unit := string(item.Unit)
+	qty := strconv.FormatFloat(item.Quantity, 'f', 4, 64)
+	unitPrice := item.UnitPrice.Decimal(10)
+
+	d := det{
+		NItem: strconv.Itoa(n),
+		Prod: prod{
+			CProd:    item.Code,
+			CEAN:     gtin,
+			XProd:    item.Description,
+			NCM:      item.NCM,
+			CEST:     item.CEST,
+			CFOP:     item.CFOP,
+			UCom:     unit,
+			QCom:     qty,
+			VUnCom:   unitPrice,
+			VProd:    item.Total.Decimal(2),
+			CEANTrib: gtin,
+			UTrib:    unit,
+			QTrib:    qty,
+			VUnTrib:  unitPrice,
+			IndTot:   "1",
+		},
+		InfAdProd: item.Details,
+	}
+
+	var rates invoice.Taxes
+	if item.Taxes != nil {
+		rates = *item.Taxes
+		if rates.Amount != 0 {
+			d.Imposto.VTotTrib = rates.Amount.Decimal(2)
+		}
+	}
+
+	var t itemTaxes
+	switch {
+	case regime == invoice.TaxRegimeSimples || regime == invoice.TaxRegimeMEI:
+		// ICMS is paid through the Simples Nacional, not highlighted on the
+		// invoice: CSOSN 102, taxed without credit.
+		d.Imposto.ICMS = &taxGroup{Detail: taxDetail{
+			XMLName: xml.Name{Local: "ICMSSN102"},
+			Orig:    "0",
+			CSOSN:   "102",
+		}}
+	case rates.ICMSPercent > 0:
+		t.base = item.Total
+		t.icms = item.Total.Mul(rates.ICMSPercent / 100)
+		d.Imposto.ICMS = &taxGroup{Detail: taxDetail{
+			XMLName: xml.Name{Local: "ICMS00"},
+			Orig:    "0",
+			CST:     "00",
+			ModBC:   "3",
+			VBC:     t.base.Decimal(2),
+			PICMS:   percent(rates.ICMSPercent),
+			VICMS:   t.icms.Decimal(2),
+		}}
+	default:
+		d.Imposto.ICMS = &taxGroup{Detail: taxDetail{
+			XMLName: xml.Name{Local: "ICMS40"},
+			Orig:    "0",
+			CST:     "40",
+		}}
+	}
+
+	if rates.PISPercent > 0 {
+		t.pis = item.Total.Mul(rates.PISPercent / 100)
+		d.Imposto.PIS = &taxGroup{Detail: taxDetail{
+			XMLName: xml.Name{Local: "PISAliq"},
+			CST:     "01",
+			VBC:     item.Total.Decimal(2),
+			PPIS:    percent(rates.PISPercent),
+			VPIS:    t.pis.Decimal(2),
+		}}
+	} else {
+		d.Imposto.PIS = &taxGroup{Detail: taxDetail{XMLName: xml.Name{Local: "PISNT"}, CST: "07"}}
+	}
+
+	if rates.COFINSPercent > 0 {
+		t.cofins = item.Total.Mul(rates.COFINSPercent / 100)
+		d.Imposto.COFINS = &taxGroup{Detail: taxDetail{
+			XMLName: xml.Name{Local: "COFINSAliq"},
+			CST:     "01",
+			VBC:     item.Total.Decimal(2),
+			PCOFINS: percent(rates.COFINSPercent),
+			VCOFINS: t.cofins.Decimal(2),
+		}}
+	} else {
+		d.Imposto.COFINS = &taxGroup{Detail: taxDetail{XMLName: xml.Name{Local: "COFINSNT"}, CST: "07"}}
+	}
+
+	return d, t
+}
+
+func fromPayments(payments []invoice.Payment, total money.BRL) *pag {
+	p := &pag{}
+	var paid money.BRL
+	for _, payment := range payments {
+		dp := detPag{
+			TPag: payment.Method.Code(),
+			VPag: payment.Amount.Decimal(2),
+		}
+		if payment.Method == invoice.PaymentCreditCard || payment.Method == invoice.PaymentDebitCard {
+			// Payment not integrated with the point of sale.
+			dp.Card = &card{TpIntegra: "2"}
+			if c := payment.Card; c != nil {
+				dp.Card.CNPJ = c.AcquirerCNPJ
+				dp.Card.TBand = invoice.CardBrandCode(c.Brand)
+				dp.Card.CAut = c.Authorization
+			}
+		}
+		p.DetPag = append(p.DetPag, dp)
+		paid = paid.Add(payment.Amount)
+	}
+	if len(p.DetPag) == 0 {
+		// The layout requires a payment group; tPag 90 means "no payment".
+		p.DetPag = append(p.DetPag, detPag{TPag: invoice.PaymentNone.Code(), VPag: money.BRL(0).Decimal(2)})
+	}
+	if paid > total {
+		p.VTroco = paid.Sub(total).Decimal(2)
+	}
+	return p
+}
+
+func percent(p float64) string {
+	return strconv.FormatFloat(p, 'f', 4, 64)
+}
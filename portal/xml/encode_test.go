@@ -0,0 +1,178 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	want, err := Parse(readFixture(t))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	data, err := Encode(want)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !IsNFe(data) {
+		t.Fatalf("IsNFe(Encode()) = false:\n%s", data)
+	}
+
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(Encode()) error = %v\n%s", err, data)
+	}
+	// Encoded documents carry no protocol: they were never authorized.
+	want.Authorization = nil
+	want.Status = ""
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestEncodeScrapedReceipt(t *testing.T) {
+	r := &invoice.Receipt{
+		Key:           "29251106057223031484650080003212191080407665",
+		Portal:        invoice.PortalBA,
+		IssueDate:     time.Date(2025, 11, 19, 20, 31, 22, 0, time.FixedZone("", -3*3600)),
+		ReceiptNumber: "321219",
+		Series:        "8",
+		Issuer:        invoice.Issuer{Name: "SENDAS DISTRIBUIDORA S/A", CNPJ: "06057223031484", StateRegID: "131694439"},
+		Items: []invoice.Item{
+			{LineNumber: 1, Code: "1", Description: "BANANA PRATA", Quantity: 1.235, Unit: invoice.UnitKilogram, UnitPrice: 699, Total: 863},
+		},
+		Subtotal: 863,
+		Total:    863,
+		Payments: []invoice.Payment{{Method: invoice.PaymentCash, Amount: 1000}},
+	}
+
+	data, err := Encode(r)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`<NFe xmlns="` + Namespace + `">`,
+		`<infNFe Id="NFe29251106057223031484650080003212191080407665" versao="4.00">`,
+		"<mod>65</mod>",
+		"<tpAmb>1</tpAmb>",
+		"<CRT>3</CRT>",
+		`<det nItem="1">`,
+		"<dhEmi>2025-11-19T20:31:22-03:00</dhEmi>",
+		"<verProc>" + ReconstructedVerProc + "</verProc>",
+		"<cEAN>SEM GTIN</cEAN>",
+		"<qCom>1.2350</qCom>",
+		"<vUnCom>6.9900000000</vUnCom>",
+		"<vProd>8.63</vProd>",
+		"<vNF>8.63</vNF>",
+		"<vTroco>1.37</vTroco>",
+		"not authorized",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Encode() missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"Signature", "protNFe", "infNFeSupl"} {
+		if bytes.Contains(data, []byte(unwanted)) {
+			t.Errorf("Encode() contains %q", unwanted)
+		}
+	}
+}
+
+func TestEncodeAmountsWithoutFloat(t *testing.T) {
+	// 2^53+1 cents cannot be represented as a float64; formatting through
+	// float would print ...992.00 or ...994.00 instead.
+	const big = money.BRL(1<<53 + 1)
+	r := &invoice.Receipt{
+		Key:       "29251106057223031484650080003212191080407665",
+		IssueDate: time.Date(2025, 11, 19, 20, 31, 22, 0, time.UTC),
+		Items:     []invoice.Item{{Description: "X", Quantity: 1, Unit: invoice.UnitUnit, UnitPrice: big, Total: big}},
+		Subtotal:  big,
+		Total:     big,
+	}
+
+	data, err := Encode(r)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	const want = "90071992547409.93"
+	if n := strings.Count(string(data), ">"+want+"<"); n != 3 {
+		t.Errorf("found %d exact %s amounts, want 3 (vProd, item vProd, vNF)", n, want)
+	}
+	if !bytes.Contains(data, []byte("<vUnCom>"+want+"00000000</vUnCom>")) {
+		t.Error("vUnCom not formatted from integer cents")
+	}
+
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got.Total != big || got.Items[0].UnitPrice != big {
+		t.Errorf("Parse(Encode()) Total = %d, UnitPrice = %d, want %d", got.Total, got.Items[0].UnitPrice, big)
+	}
+}
+
+func TestEncodeFromKeyAndIssuer(t *testing.T) {
+	r := &invoice.Receipt{
+		Key:           "29251106057223031484550080003212191080407662",
+		IssueDate:     time.Date(2025, 11, 19, 20, 31, 22, 0, time.UTC),
+		Authorization: &invoice.Authorization{Environment: invoice.EnvironmentHomologation},
+		Issuer:        invoice.Issuer{Name: "MERCADINHO", CNPJ: "06057223031484", TaxRegime: invoice.TaxRegimeSimples},
+		Items: []invoice.Item{
+			{LineNumber: 3, Description: "A", Quantity: 1, Unit: invoice.UnitUnit, UnitPrice: 100, Total: 100, Taxes: &invoice.Taxes{ICMSPercent: 18}},
+			{Description: "B", Quantity: 1, Unit: invoice.UnitUnit, UnitPrice: 200, Total: 200},
+		},
+		Subtotal: 300,
+		Total:    300,
+	}
+
+	data, err := Encode(r)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	for _, want := range []string{
+		"<mod>55</mod>",
+		"<tpAmb>2</tpAmb>",
+		"<CRT>1</CRT>",
+		`<det nItem="3">`,
+		`<det nItem="2">`,
+		"<ICMSSN102><orig>0</orig><CSOSN>102</CSOSN></ICMSSN102>",
+		"<vICMS>0.00</vICMS>",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("Encode() missing %q", want)
+		}
+	}
+	if bytes.Contains(data, []byte("ICMS00")) {
+		t.Error("Encode() wrote a normal regime ICMS group for a Simples Nacional issuer")
+	}
+
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(Encode()) error = %v", err)
+	}
+	if got.Issuer.TaxRegime != invoice.TaxRegimeSimples {
+		t.Errorf("Parse(Encode()) TaxRegime = %q, want %q", got.Issuer.TaxRegime, invoice.TaxRegimeSimples)
+	}
+}
+
+func TestEncodeWithoutIssueDate(t *testing.T) {
+	r := &invoice.Receipt{Key: "29251106057223031484650080003212191080407665"}
+	if _, err := Encode(r); !errors.Is(err, ErrNoIssueDate) {
+		t.Errorf("Encode() error = %v, want %v", err, ErrNoIssueDate)
+	}
+}
+
+func TestEncodeInvalidKey(t *testing.T) {
+	if _, err := Encode(&invoice.Receipt{Key: "123"}); err == nil {
+		t.Error("Encode() expected error for invalid key")
+	}
+}
@@ -0,0 +1,273 @@
+// Package xml maps authorized NF-e/NFC-e XML documents (nfeProc or NFe)
+// to invoice.Receipt and encodes receipts back to the NFC-e layout.
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
+	"github.com/glwbr/brisa/parse"
+)
+
+var (
+	ErrNotNFe      = errors.New("document is not an NF-e XML")
+	ErrKeyMismatch = errors.New("protocol access key does not match the document")
+)
+
+// IsNFe reports whether data looks like an NF-e XML document, i.e. its root
+// element is nfeProc or NFe.
+func IsNFe(data []byte) bool {
+	root, err := rootElement(data)
+	return err == nil && (root == "nfeProc" || root == "NFe")
+}
+
+// Parse decodes an nfeProc or bare NFe document into a Receipt.
+func Parse(data []byte) (*invoice.Receipt, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotNFe, err)
+	}
+
+	var doc nfeProc
+	switch root {
+	case "nfeProc":
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode nfeProc: %w", err)
+		}
+	case "NFe":
+		if err := xml.Unmarshal(data, &doc.NFe); err != nil {
+			return nil, fmt.Errorf("decode NFe: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: root element %q", ErrNotNFe, root)
+	}
+
+	return toReceipt(&doc)
+}
+
+func rootElement(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func toReceipt(doc *nfeProc) (*invoice.Receipt, error) {
+	inf := &doc.NFe.InfNFe
+
+	accessKey, err := invoice.ParseAccessKey(strings.TrimPrefix(inf.ID, "NFe"))
+	if err != nil {
+		return nil, fmt.Errorf("infNFe Id: %w", err)
+	}
+	key := accessKey.String()
+	if doc.ProtNFe != nil && doc.ProtNFe.InfProt.ChNFe != "" && doc.ProtNFe.InfProt.ChNFe != key {
+		return nil, fmt.Errorf("%w: %s != %s", ErrKeyMismatch, doc.ProtNFe.InfProt.ChNFe, key)
+	}
+
+	var dec decimals
+	tot := &inf.Total.ICMSTot
+	r := &invoice.Receipt{
+		Key:           key,
+		ReceiptNumber: inf.Ide.NNF,
+		Series:        inf.Ide.Serie,
+		Issuer: invoice.Issuer{
+			Name:        inf.Emit.XNome,
+			CNPJ:        parse.FirstNonEmpty(inf.Emit.CNPJ, inf.Emit.CPF),
+			TradeName:   inf.Emit.XFant,
+			StateRegID:  inf.Emit.IE,
+			MunicipalID: inf.Emit.IM,
+			Phone:       inf.Emit.EnderEmit.Fone,
+			TaxRegime:   invoice.ParseTaxRegime(inf.Emit.CRT),
+			Address: invoice.Address{
+				Street:     inf.Emit.EnderEmit.XLgr,
+				Number:     inf.Emit.EnderEmit.Nro,
+				Complement: inf.Emit.EnderEmit.XCpl,
+				District:   inf.Emit.EnderEmit.XBairro,
+				City:       inf.Emit.EnderEmit.XMun,
+				CityCode:   inf.Emit.EnderEmit.CMun,
+				State:      inf.Emit.EnderEmit.UF,
+				ZipCode:    inf.Emit.EnderEmit.CEP,
+			},
+		},
+		Subtotal:   dec.parse("ICMSTot/vProd", tot.VProd),
+		Discount:   dec.parse("ICMSTot/vDesc", tot.VDesc),
+		Freight:    dec.parse("ICMSTot/vFrete", tot.VFrete),
+		Insurance:  dec.parse("ICMSTot/vSeg", tot.VSeg),
+		OtherCosts: dec.parse("ICMSTot/vOutro", tot.VOutro),
+		Total:      dec.parse("ICMSTot/vNF", tot.VNF),
+		Taxes:      invoice.Taxes{Amount: dec.parse("ICMSTot/vTotTrib", tot.VTotTrib)},
+		TaxTotals: &invoice.TaxTotals{
+			ICMSBase:  dec.parse("ICMSTot/vBC", tot.VBC),
+			ICMS:      dec.parse("ICMSTot/vICMS", tot.VICMS),
+			ICMSST:    dec.parse("ICMSTot/vST", tot.VST),
+			IPI:       dec.parse("ICMSTot/vIPI", tot.VIPI),
+			PIS:       dec.parse("ICMSTot/vPIS", tot.VPIS),
+			COFINS:    dec.parse("ICMSTot/vCOFINS", tot.VCOFINS),
+			ImportTax: dec.parse("ICMSTot/vII", tot.VII),
+		},
+	}
+
+	if state, ok := invoice.StateByCode(parse.Int(inf.Ide.CUF)); ok {
+		r.Portal = invoice.Portal(state)
+	}
+
+	if inf.Ide.DhEmi != "" {
+		ts, err := time.Parse(time.RFC3339, inf.Ide.DhEmi)
+		if err != nil {
+			return nil, fmt.Errorf("parse dhEmi: %w", err)
+		}
+		r.IssueDate = ts
+	}
+
+	if doc.ProtNFe != nil {
+		prot := doc.ProtNFe.InfProt
+		r.Authorization = &invoice.Authorization{
+			Protocol:      prot.NProt,
+			StatusCode:    parse.Int(prot.CStat),
+			StatusMessage: prot.XMotivo,
+			Environment:   invoice.ParseEnvironment(prot.TpAmb),
+			EmissionType:  parse.Int(inf.Ide.TpEmis),
+		}
+		if prot.DhRecbto != "" {
+			ts, err := time.Parse(time.RFC3339, prot.DhRecbto)
+			if err != nil {
+				return nil, fmt.Errorf("parse dhRecbto: %w", err)
+			}
+			r.Authorization.AuthorizedAt = ts
+		}
+		r.Status = invoice.StatusFromCode(r.Authorization.StatusCode)
+	}
+
+	if adic := inf.InfAdic; adic != nil {
+		r.AdditionalInfo = joinNonEmpty("\n", adic.InfAdFisco, adic.InfCpl)
+	}
+
+	if inf.Dest != nil {
+		r.Consumer = invoice.Consumer{
+			Document: parse.FirstNonEmpty(inf.Dest.CPF, inf.Dest.CNPJ, inf.Dest.IDEstrangeiro),
+			Name:     inf.Dest.XNome,
+		}
+	}
+
+	for _, d := range inf.Det {
+		r.Items = append(r.Items, toItem(d, &dec))
+	}
+
+	if inf.Pag != nil {
+		for _, p := range inf.Pag.DetPag {
+			payment := invoice.Payment{
+				Method: invoice.ParsePaymentCode(p.TPag),
+				Amount: dec.parse("detPag/vPag", p.VPag),
+			}
+			if c := p.Card; c != nil && (c.CNPJ != "" || c.TBand != "" || c.CAut != "") {
+				payment.Card = &invoice.Card{
+					Brand:         invoice.ParseCardBrand(c.TBand),
+					AcquirerCNPJ:  c.CNPJ,
+					Authorization: c.CAut,
+				}
+			}
+			r.Payments = append(r.Payments, payment)
+		}
+		r.Change = dec.parse("pag/vTroco", inf.Pag.VTroco)
+	}
+
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	return r, nil
+}
+
+func toItem(d det, dec *decimals) invoice.Item {
+	p := d.Prod
+	decimal := func(element, s string) money.BRL {
+		return dec.parse("det["+d.NItem+"]/"+element, s)
+	}
+	item := invoice.Item{
+		LineNumber:  parse.Int(d.NItem),
+		Code:        p.CProd,
+		Description: p.XProd,
+		Details:     d.InfAdProd,
+		Quantity:    quantity(p.QCom),
+		Unit:        invoice.ParseUnit(strings.ToUpper(p.UCom)),
+		UnitPrice:   decimal("prod/vUnCom", p.VUnCom),
+		Total:       decimal("prod/vProd", p.VProd),
+		NCM:         p.NCM,
+		CFOP:        p.CFOP,
+		CEST:        p.CEST,
+	}
+	if gtin := parse.FirstNonEmpty(p.CEAN, p.CEANTrib); gtin != "" && !strings.EqualFold(gtin, "SEM GTIN") {
+		item.GTIN = gtin
+	}
+
+	taxes := &invoice.Taxes{Amount: decimal("imposto/vTotTrib", d.Imposto.VTotTrib)}
+	var sum money.BRL
+	if g := d.Imposto.ICMS; g != nil {
+		taxes.ICMSPercent = quantity(g.Detail.PICMS)
+		sum = sum.Add(decimal("ICMS/vICMS", g.Detail.VICMS))
+	}
+	if g := d.Imposto.IPI; g != nil {
+		taxes.IPIPercent = quantity(g.Detail.PIPI)
+		sum = sum.Add(decimal("IPI/vIPI", g.Detail.VIPI))
+	}
+	if g := d.Imposto.PIS; g != nil {
+		taxes.PISPercent = quantity(g.Detail.PPIS)
+		sum = sum.Add(decimal("PIS/vPIS", g.Detail.VPIS))
+	}
+	if g := d.Imposto.COFINS; g != nil {
+		taxes.COFINSPercent = quantity(g.Detail.PCOFINS)
+		sum = sum.Add(decimal("COFINS/vCOFINS", g.Detail.VCOFINS))
+	}
+	if taxes.Amount == 0 {
+		taxes.Amount = sum
+	}
+	if *taxes != (invoice.Taxes{}) {
+		item.Taxes = taxes
+	}
+	return item
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var kept []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// decimals parses XML decimal amounts, keeping the first error so that a
+// whole document can be checked at once.
+type decimals struct {
+	err error
+}
+
+// parse returns the amount of element, zero when it is absent.
+func (d *decimals) parse(element, s string) money.BRL {
+	if strings.TrimSpace(s) == "" {
+		return 0
+	}
+	v, err := money.ParseDecimal(s)
+	if err != nil && d.err == nil {
+		d.err = fmt.Errorf("parse %s: %w", element, err)
+	}
+	return v
+}
+
+func quantity(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
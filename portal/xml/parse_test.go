@@ -0,0 +1,150 @@
+package xml
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/money"
+)
+
+func readFixture(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../testdata/nfce_proc.xml")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse(readFixture(t))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if r.Key != "29251106057223031484650080003212191080407665" {
+		t.Errorf("Key = %q", r.Key)
+	}
+	if r.Portal != invoice.PortalBA {
+		t.Errorf("Portal = %q, want %q", r.Portal, invoice.PortalBA)
+	}
+	if r.Series != "8" || r.ReceiptNumber != "321219" {
+		t.Errorf("Series/Number = %q/%q", r.Series, r.ReceiptNumber)
+	}
+	wantDate := time.Date(2025, 11, 19, 20, 31, 22, 0, time.FixedZone("", -3*3600))
+	if !r.IssueDate.Equal(wantDate) {
+		t.Errorf("IssueDate = %v, want %v", r.IssueDate, wantDate)
+	}
+
+	if r.Issuer.CNPJ != "06057223031484" || r.Issuer.TradeName != "ASSAI ATACADISTA" || r.Issuer.Address.City != "SALVADOR" {
+		t.Errorf("Issuer = %+v", r.Issuer)
+	}
+	if r.Issuer.Address.CityCode != "2927408" || r.Issuer.Phone != "7133334444" || r.Issuer.TaxRegime != invoice.TaxRegimeNormal {
+		t.Errorf("Issuer = %+v", r.Issuer)
+	}
+	if r.Consumer.Document != "12345678909" || r.Consumer.Name != "MARIA DA SILVA" {
+		t.Errorf("Consumer = %+v", r.Consumer)
+	}
+
+	if r.Subtotal != 12209 || r.Discount != 209 || r.Total != 12000 {
+		t.Errorf("Subtotal/Discount/Total = %d/%d/%d", r.Subtotal, r.Discount, r.Total)
+	}
+	wantTotals := invoice.TaxTotals{ICMSBase: 11137, ICMS: 1527, PIS: 92, COFINS: 425}
+	if r.TaxTotals == nil || *r.TaxTotals != wantTotals {
+		t.Errorf("TaxTotals = %+v, want %+v", r.TaxTotals, wantTotals)
+	}
+	if r.Taxes.Amount != 2543 {
+		t.Errorf("Taxes.Amount = %d, want 2543", r.Taxes.Amount)
+	}
+
+	if len(r.Items) != 3 {
+		t.Fatalf("len(Items) = %d, want 3", len(r.Items))
+	}
+	banana := r.Items[1]
+	if banana.LineNumber != 2 || banana.Quantity != 1.235 || banana.Unit != invoice.UnitKilogram ||
+		banana.UnitPrice != 699 || banana.Total != 863 || banana.GTIN != "" || banana.Details != "PESO CONFERIDO NO CAIXA" {
+		t.Errorf("Items[1] = %+v", banana)
+	}
+	rice := r.Items[0]
+	if rice.GTIN != "7896006716129" || rice.Taxes == nil || rice.Taxes.ICMSPercent != 7 || rice.Taxes.PISPercent != 1.65 || rice.Taxes.Amount != 1011 {
+		t.Errorf("Items[0] = %+v, taxes %+v", rice, rice.Taxes)
+	}
+
+	wantAuth := invoice.Authorization{
+		Protocol:      "229251430293306",
+		AuthorizedAt:  time.Date(2025, 11, 19, 20, 31, 23, 0, time.FixedZone("", -3*3600)),
+		StatusCode:    invoice.CStatAuthorized,
+		StatusMessage: "Autorizado o uso da NF-e",
+		Environment:   invoice.EnvironmentProduction,
+		EmissionType:  invoice.EmissionNormal,
+	}
+	if a := r.Authorization; a == nil || a.Protocol != wantAuth.Protocol || !a.AuthorizedAt.Equal(wantAuth.AuthorizedAt) ||
+		a.StatusCode != wantAuth.StatusCode || a.StatusMessage != wantAuth.StatusMessage ||
+		a.Environment != wantAuth.Environment || a.EmissionType != wantAuth.EmissionType {
+		t.Errorf("Authorization = %+v, want %+v", r.Authorization, wantAuth)
+	}
+	if r.Status != invoice.StatusAuthorized {
+		t.Errorf("Status = %q, want %q", r.Status, invoice.StatusAuthorized)
+	}
+	if want := "ICMS RECOLHIDO CONFORME LEGISLACAO\nTributos aproximados: R$ 25,43. Obrigado pela preferencia!"; r.AdditionalInfo != want {
+		t.Errorf("AdditionalInfo = %q, want %q", r.AdditionalInfo, want)
+	}
+
+	wantPayments := []invoice.Payment{
+		{Method: invoice.PaymentPix, Amount: money.BRL(5000)},
+		{Method: invoice.PaymentCreditCard, Amount: money.BRL(7000), Card: &invoice.Card{
+			Brand:         "Mastercard",
+			AcquirerCNPJ:  "01027058000191",
+			Authorization: "123456",
+		}},
+	}
+	if len(r.Payments) != len(wantPayments) {
+		t.Fatalf("Payments = %+v", r.Payments)
+	}
+	for i, p := range wantPayments {
+		if !reflect.DeepEqual(r.Payments[i], p) {
+			t.Errorf("Payments[%d] = %+v, want %+v", i, r.Payments[i], p)
+		}
+	}
+}
+
+func TestIsNFe(t *testing.T) {
+	if !IsNFe(readFixture(t)) {
+		t.Error("IsNFe(fixture) = false")
+	}
+	html, err := os.ReadFile("../../testdata/nfe_tab.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsNFe(html) {
+		t.Error("IsNFe(html) = true")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse([]byte(`<html><body/></html>`)); !errors.Is(err, ErrNotNFe) {
+		t.Errorf("Parse(html) error = %v, want %v", err, ErrNotNFe)
+	}
+
+	doc := `<nfeProc><NFe><infNFe Id="NFe29251106057223031484650080003212191080407665"/></NFe>` +
+		`<protNFe><infProt><chNFe>29251106057223031484650080003212191080407664</chNFe></infProt></protNFe></nfeProc>`
+	if _, err := Parse([]byte(doc)); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Parse(mismatch) error = %v, want %v", err, ErrKeyMismatch)
+	}
+
+	badKey := `<NFe><infNFe Id="NFe29251106057223031484650080003212191080407664"/></NFe>`
+	if _, err := Parse([]byte(badKey)); !errors.Is(err, invoice.ErrAccessKeyCheckDigit) {
+		t.Errorf("Parse(bad check digit) error = %v, want %v", err, invoice.ErrAccessKeyCheckDigit)
+	}
+
+	malformed := strings.Replace(string(readFixture(t)), "<vUnCom>27.99", "<vUnCom>27,99", 1)
+	_, err := Parse([]byte(malformed))
+	if !errors.Is(err, money.ErrInvalidFormat) || !strings.Contains(err.Error(), "det[1]/prod/vUnCom") {
+		t.Errorf("Parse(malformed amount) error = %v, want %v on det[1]/prod/vUnCom", err, money.ErrInvalidFormat)
+	}
+}
@@ -0,0 +1,221 @@
+package xml
+
+import "encoding/xml"
+
+// The types below mirror the subset of the NF-e 4.00 layout brisa maps to
+// invoice.Receipt. Field order follows the schema sequence.
+
+type nfeProc struct {
+	XMLName xml.Name `xml:"nfeProc"`
+	Version string   `xml:"versao,attr"`
+	NFe     nfe      `xml:"NFe"`
+	ProtNFe *protNFe `xml:"protNFe"`
+}
+
+type nfe struct {
+	XMLName    xml.Name
+	InfNFe     infNFe      `xml:"infNFe"`
+	InfNFeSupl *infNFeSupl `xml:"infNFeSupl"`
+}
+
+type infNFe struct {
+	ID      string   `xml:"Id,attr"`
+	Version string   `xml:"versao,attr"`
+	Ide     ide      `xml:"ide"`
+	Emit    emit     `xml:"emit"`
+	Dest    *dest    `xml:"dest"`
+	Det     []det    `xml:"det"`
+	Total   total    `xml:"total"`
+	Transp  *transp  `xml:"transp"`
+	Pag     *pag     `xml:"pag"`
+	InfAdic *infAdic `xml:"infAdic"`
+}
+
+type ide struct {
+	CUF      string `xml:"cUF"`
+	CNF      string `xml:"cNF"`
+	NatOp    string `xml:"natOp"`
+	Mod      string `xml:"mod"`
+	Serie    string `xml:"serie"`
+	NNF      string `xml:"nNF"`
+	DhEmi    string `xml:"dhEmi"`
+	TpNF     string `xml:"tpNF"`
+	IDDest   string `xml:"idDest"`
+	CMunFG   string `xml:"cMunFG"`
+	TpImp    string `xml:"tpImp"`
+	TpEmis   string `xml:"tpEmis"`
+	CDV      string `xml:"cDV"`
+	TpAmb    string `xml:"tpAmb"`
+	FinNFe   string `xml:"finNFe"`
+	IndFinal string `xml:"indFinal"`
+	IndPres  string `xml:"indPres"`
+	ProcEmi  string `xml:"procEmi"`
+	VerProc  string `xml:"verProc"`
+}
+
+type emit struct {
+	CNPJ      string `xml:"CNPJ,omitempty"`
+	CPF       string `xml:"CPF,omitempty"`
+	XNome     string `xml:"xNome"`
+	XFant     string `xml:"xFant,omitempty"`
+	EnderEmit ender  `xml:"enderEmit"`
+	IE        string `xml:"IE"`
+	IM        string `xml:"IM,omitempty"`
+	CNAE      string `xml:"CNAE,omitempty"`
+	CRT       string `xml:"CRT"`
+}
+
+type ender struct {
+	XLgr    string `xml:"xLgr"`
+	Nro     string `xml:"nro"`
+	XCpl    string `xml:"xCpl,omitempty"`
+	XBairro string `xml:"xBairro"`
+	CMun    string `xml:"cMun"`
+	XMun    string `xml:"xMun"`
+	UF      string `xml:"UF"`
+	CEP     string `xml:"CEP,omitempty"`
+	CPais   string `xml:"cPais,omitempty"`
+	XPais   string `xml:"xPais,omitempty"`
+	Fone    string `xml:"fone,omitempty"`
+}
+
+type dest struct {
+	CNPJ          string `xml:"CNPJ,omitempty"`
+	CPF           string `xml:"CPF,omitempty"`
+	IDEstrangeiro string `xml:"idEstrangeiro,omitempty"`
+	XNome         string `xml:"xNome,omitempty"`
+	IndIEDest     string `xml:"indIEDest"`
+}
+
+type det struct {
+	NItem     string  `xml:"nItem,attr"`
+	Prod      prod    `xml:"prod"`
+	Imposto   imposto `xml:"imposto"`
+	InfAdProd string  `xml:"infAdProd,omitempty"`
+}
+
+type prod struct {
+	CProd    string `xml:"cProd"`
+	CEAN     string `xml:"cEAN"`
+	XProd    string `xml:"xProd"`
+	NCM      string `xml:"NCM"`
+	CEST     string `xml:"CEST,omitempty"`
+	CFOP     string `xml:"CFOP"`
+	UCom     string `xml:"uCom"`
+	QCom     string `xml:"qCom"`
+	VUnCom   string `xml:"vUnCom"`
+	VProd    string `xml:"vProd"`
+	CEANTrib string `xml:"cEANTrib"`
+	UTrib    string `xml:"uTrib"`
+	QTrib    string `xml:"qTrib"`
+	VUnTrib  string `xml:"vUnTrib"`
+	VDesc    string `xml:"vDesc,omitempty"`
+	IndTot   string `xml:"indTot"`
+}
+
+type imposto struct {
+	VTotTrib string    `xml:"vTotTrib,omitempty"`
+	ICMS     *taxGroup `xml:"ICMS"`
+	IPI      *taxGroup `xml:"IPI"`
+	PIS      *taxGroup `xml:"PIS"`
+	COFINS   *taxGroup `xml:"COFINS"`
+}
+
+// taxGroup wraps the CST-specific element (ICMS00, PISAliq, IPITrib, ...)
+// that carries the rates and values of a tax.
+type taxGroup struct {
+	Detail taxDetail `xml:",any"`
+}
+
+type taxDetail struct {
+	XMLName xml.Name
+	Orig    string `xml:"orig,omitempty"`
+	CST     string `xml:"CST,omitempty"`
+	CSOSN   string `xml:"CSOSN,omitempty"`
+	ModBC   string `xml:"modBC,omitempty"`
+	VBC     string `xml:"vBC,omitempty"`
+	PICMS   string `xml:"pICMS,omitempty"`
+	VICMS   string `xml:"vICMS,omitempty"`
+	PIPI    string `xml:"pIPI,omitempty"`
+	VIPI    string `xml:"vIPI,omitempty"`
+	PPIS    string `xml:"pPIS,omitempty"`
+	VPIS    string `xml:"vPIS,omitempty"`
+	PCOFINS string `xml:"pCOFINS,omitempty"`
+	VCOFINS string `xml:"vCOFINS,omitempty"`
+}
+
+type total struct {
+	ICMSTot icmsTot `xml:"ICMSTot"`
+}
+
+type icmsTot struct {
+	VBC        string `xml:"vBC"`
+	VICMS      string `xml:"vICMS"`
+	VICMSDeson string `xml:"vICMSDeson"`
+	VFCP       string `xml:"vFCP"`
+	VBCST      string `xml:"vBCST"`
+	VST        string `xml:"vST"`
+	VFCPST     string `xml:"vFCPST"`
+	VFCPSTRet  string `xml:"vFCPSTRet"`
+	VProd      string `xml:"vProd"`
+	VFrete     string `xml:"vFrete"`
+	VSeg       string `xml:"vSeg"`
+	VDesc      string `xml:"vDesc"`
+	VII        string `xml:"vII"`
+	VIPI       string `xml:"vIPI"`
+	VIPIDevol  string `xml:"vIPIDevol"`
+	VPIS       string `xml:"vPIS"`
+	VCOFINS    string `xml:"vCOFINS"`
+	VOutro     string `xml:"vOutro"`
+	VNF        string `xml:"vNF"`
+	VTotTrib   string `xml:"vTotTrib,omitempty"`
+}
+
+type transp struct {
+	ModFrete string `xml:"modFrete"`
+}
+
+type pag struct {
+	DetPag []detPag `xml:"detPag"`
+	VTroco string   `xml:"vTroco,omitempty"`
+}
+
+type detPag struct {
+	IndPag string `xml:"indPag,omitempty"`
+	TPag   string `xml:"tPag"`
+	VPag   string `xml:"vPag"`
+	Card   *card  `xml:"card"`
+}
+
+type card struct {
+	TpIntegra string `xml:"tpIntegra"`
+	CNPJ      string `xml:"CNPJ,omitempty"`
+	TBand     string `xml:"tBand,omitempty"`
+	CAut      string `xml:"cAut,omitempty"`
+}
+
+type infAdic struct {
+	InfAdFisco string `xml:"infAdFisco,omitempty"`
+	InfCpl     string `xml:"infCpl,omitempty"`
+}
+
+type infNFeSupl struct {
+	QRCode   string `xml:"qrCode"`
+	URLChave string `xml:"urlChave"`
+}
+
+type protNFe struct {
+	Version string  `xml:"versao,attr"`
+	InfProt infProt `xml:"infProt"`
+}
+
+type infProt struct {
+	TpAmb    string `xml:"tpAmb"`
+	VerAplic string `xml:"verAplic"`
+	ChNFe    string `xml:"chNFe"`
+	DhRecbto string `xml:"dhRecbto"`
+	NProt    string `xml:"nProt"`
+	DigVal   string `xml:"digVal"`
+	CStat    string `xml:"cStat"`
+	XMotivo  string `xml:"xMotivo"`
+}
@@ -0,0 +1,143 @@
+package qrcode
+
+import (
+	"image"
+	"image/color"
+)
+
+// bitMatrix is a black/white raster; true means a dark pixel or module.
+type bitMatrix struct {
+	width, height int
+	bits          []bool
+}
+
+func newBitMatrix(width, height int) *bitMatrix {
+	return &bitMatrix{width: width, height: height, bits: make([]bool, width*height)}
+}
+
+func (m *bitMatrix) get(x, y int) bool { return m.bits[y*m.width+x] }
+
+func (m *bitMatrix) set(x, y int, v bool) { m.bits[y*m.width+x] = v }
+
+// transpose mirrors the matrix over its main diagonal.
+func (m *bitMatrix) transpose() *bitMatrix {
+	t := newBitMatrix(m.height, m.width)
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			t.set(y, x, m.get(x, y))
+		}
+	}
+	return t
+}
+
+const (
+	blockSize       = 8
+	minDynamicRange = 24
+)
+
+// luminance converts an image to 8-bit gray levels.
+func luminance(img image.Image) ([]uint8, int, int) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	lum := make([]uint8, w*h)
+
+	switch src := img.(type) {
+	case *image.Gray:
+		for y := 0; y < h; y++ {
+			copy(lum[y*w:(y+1)*w], src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):])
+		}
+	case *image.YCbCr:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				lum[y*w+x] = src.Y[src.YOffset(b.Min.X+x, b.Min.Y+y)]
+			}
+		}
+	default:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				lum[y*w+x] = color.GrayModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.Gray).Y
+			}
+		}
+	}
+	return lum, w, h
+}
+
+// binarize applies a local threshold computed over 8x8 blocks, averaged over
+// a 5x5 block neighbourhood, so uneven lighting in photos is tolerated.
+func binarize(img image.Image) *bitMatrix {
+	lum, w, h := luminance(img)
+	m := newBitMatrix(w, h)
+	if w < blockSize*5 || h < blockSize*5 {
+		globalThreshold(lum, m)
+		return m
+	}
+
+	subW := (w + blockSize - 1) / blockSize
+	subH := (h + blockSize - 1) / blockSize
+	averages := make([]int, subW*subH)
+
+	for by := 0; by < subH; by++ {
+		y0 := min(by*blockSize, h-blockSize)
+		for bx := 0; bx < subW; bx++ {
+			x0 := min(bx*blockSize, w-blockSize)
+			sum, lo, hi := 0, 255, 0
+			for y := y0; y < y0+blockSize; y++ {
+				for _, p := range lum[y*w+x0 : y*w+x0+blockSize] {
+					v := int(p)
+					sum += v
+					lo = min(lo, v)
+					hi = max(hi, v)
+				}
+			}
+			avg := sum / (blockSize * blockSize)
+			if hi-lo <= minDynamicRange {
+				// Flat block: assume background unless neighbours say otherwise.
+				avg = lo / 2
+				if by > 0 && bx > 0 {
+					neighbour := (averages[(by-1)*subW+bx] + 2*averages[by*subW+bx-1] + averages[(by-1)*subW+bx-1]) / 4
+					if lo < neighbour {
+						avg = neighbour
+					}
+				}
+			}
+			averages[by*subW+bx] = avg
+		}
+	}
+
+	for by := 0; by < subH; by++ {
+		y0 := min(by*blockSize, h-blockSize)
+		cy := min(max(by, 2), subH-3)
+		for bx := 0; bx < subW; bx++ {
+			x0 := min(bx*blockSize, w-blockSize)
+			cx := min(max(bx, 2), subW-3)
+			sum := 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += averages[(cy+dy)*subW+cx+dx]
+				}
+			}
+			threshold := sum / 25
+			for y := y0; y < y0+blockSize; y++ {
+				for x := x0; x < x0+blockSize; x++ {
+					m.set(x, y, int(lum[y*w+x]) <= threshold)
+				}
+			}
+		}
+	}
+	return m
+}
+
+// globalThreshold binarizes small images around the mean luminance.
+func globalThreshold(lum []uint8, m *bitMatrix) {
+	if len(lum) == 0 {
+		return
+	}
+	sum := 0
+	for _, p := range lum {
+		sum += int(p)
+	}
+	threshold := sum / len(lum)
+	for i, p := range lum {
+		m.bits[i] = int(p) < threshold
+	}
+}
@@ -0,0 +1,431 @@
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	errFormat      = errors.New("unreadable format information")
+	errVersion     = errors.New("unreadable version information")
+	errUnsupported = errors.New("unsupported data mode")
+	errTruncated   = errors.New("truncated data")
+)
+
+// decodeGrid decodes a sampled module grid, trying its mirror image if the
+// symbol does not read as is.
+func decodeGrid(grid *bitMatrix) (string, error) {
+	text, err := decodeModules(grid)
+	if err == nil {
+		return text, nil
+	}
+	if mirrored, mErr := decodeModules(grid.transpose()); mErr == nil {
+		return mirrored, nil
+	}
+	return "", err
+}
+
+func decodeModules(grid *bitMatrix) (string, error) {
+	dim := grid.width
+	version := (dim - 17) / 4
+
+	level, mask, ok := readFormat(grid)
+	if !ok {
+		return "", errFormat
+	}
+	if version >= 7 {
+		if version, ok = readVersion(grid); !ok || dimensionForVersion(version) != dim {
+			return "", errVersion
+		}
+	}
+
+	codewords := readCodewords(grid, version, mask)
+	data, err := correctBlocks(codewords, version, level)
+	if err != nil {
+		return "", err
+	}
+	return decodeSegments(data, version)
+}
+
+func readFormat(grid *bitMatrix) (ecLevel, int, bool) {
+	dim := grid.width
+	bit := func(acc int, x, y int) int {
+		acc <<= 1
+		if grid.get(x, y) {
+			acc |= 1
+		}
+		return acc
+	}
+
+	copy1 := 0
+	for x := 0; x <= 5; x++ {
+		copy1 = bit(copy1, x, 8)
+	}
+	copy1 = bit(copy1, 7, 8)
+	copy1 = bit(copy1, 8, 8)
+	copy1 = bit(copy1, 8, 7)
+	for y := 5; y >= 0; y-- {
+		copy1 = bit(copy1, 8, y)
+	}
+
+	copy2 := 0
+	for y := dim - 1; y >= dim-7; y-- {
+		copy2 = bit(copy2, 8, y)
+	}
+	for x := dim - 8; x < dim; x++ {
+		copy2 = bit(copy2, x, 8)
+	}
+
+	return decodeFormat(copy1, copy2)
+}
+
+func readVersion(grid *bitMatrix) (int, bool) {
+	dim := grid.width
+	read := func(transposed bool) int {
+		acc := 0
+		for a := 5; a >= 0; a-- {
+			for b := dim - 9; b >= dim-11; b-- {
+				x, y := b, a
+				if transposed {
+					x, y = a, b
+				}
+				acc <<= 1
+				if grid.get(x, y) {
+					acc |= 1
+				}
+			}
+		}
+		return acc
+	}
+	if v, ok := decodeVersion(read(false)); ok {
+		return v, true
+	}
+	return decodeVersion(read(true))
+}
+
+// functionPatterns marks the modules that do not carry data.
+func functionPatterns(version int) *bitMatrix {
+	dim := dimensionForVersion(version)
+	m := newBitMatrix(dim, dim)
+	region := func(x0, y0, w, h int) {
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				m.set(x, y, true)
+			}
+		}
+	}
+
+	region(0, 0, 9, 9)
+	region(dim-8, 0, 8, 9)
+	region(0, dim-8, 9, 8)
+
+	pos := alignmentPositions(version)
+	for _, y := range pos {
+		for _, x := range pos {
+			if (x == 6 && y == 6) || (x == 6 && y == pos[len(pos)-1]) || (x == pos[len(pos)-1] && y == 6) {
+				continue
+			}
+			region(x-2, y-2, 5, 5)
+		}
+	}
+
+	region(6, 9, 1, dim-17)
+	region(9, 6, dim-17, 1)
+
+	if version >= 7 {
+		region(dim-11, 0, 3, 6)
+		region(0, dim-11, 6, 3)
+	}
+	return m
+}
+
+func masked(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return (row*col)%2+(row*col)%3 == 0
+	case 6:
+		return ((row*col)%2+(row*col)%3)%2 == 0
+	default:
+		return ((row+col)%2+(row*col)%3)%2 == 0
+	}
+}
+
+// readCodewords walks the two-column zigzag from the bottom-right corner,
+// skipping function patterns and removing the data mask.
+func readCodewords(grid *bitMatrix, version, mask int) []byte {
+	dim := grid.width
+	function := functionPatterns(version)
+	out := make([]byte, 0, rawCodewords(version))
+
+	var current byte
+	nbits := 0
+	up := true
+	for right := dim - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+		for i := 0; i < dim; i++ {
+			y := i
+			if up {
+				y = dim - 1 - i
+			}
+			for col := 0; col < 2; col++ {
+				x := right - col
+				if function.get(x, y) {
+					continue
+				}
+				current <<= 1
+				if grid.get(x, y) != masked(mask, y, x) {
+					current |= 1
+				}
+				nbits++
+				if nbits == 8 {
+					out = append(out, current)
+					current, nbits = 0, 0
+				}
+			}
+		}
+		up = !up
+	}
+	return out
+}
+
+// correctBlocks de-interleaves the codewords into their blocks, applies
+// Reed-Solomon correction and returns the concatenated data codewords.
+func correctBlocks(codewords []byte, version int, level ecLevel) ([]byte, error) {
+	total := rawCodewords(version)
+	if len(codewords) < total {
+		return nil, errTruncated
+	}
+	numBlocks := ecBlocks[level][version]
+	numEC := ecCodewordsPerBlock[level][version]
+	shortLen := total / numBlocks
+	numShort := numBlocks - total%numBlocks
+
+	blocks := make([][]byte, numBlocks)
+	dataLens := make([]int, numBlocks)
+	for i := range blocks {
+		dataLens[i] = shortLen - numEC
+		if i >= numShort {
+			dataLens[i]++
+		}
+		blocks[i] = make([]byte, 0, dataLens[i]+numEC)
+	}
+
+	k := 0
+	for i := 0; i < shortLen-numEC+1; i++ {
+		for b := range blocks {
+			if i < dataLens[b] {
+				blocks[b] = append(blocks[b], codewords[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < numEC; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[k])
+			k++
+		}
+	}
+
+	var data []byte
+	for b, block := range blocks {
+		if err := rsCorrect(block, numEC); err != nil {
+			return nil, fmt.Errorf("block %d: %w", b, err)
+		}
+		data = append(data, block[:dataLens[b]]...)
+	}
+	return data, nil
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) available() int { return len(r.data)*8 - r.pos }
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.available() {
+		return 0, errTruncated
+	}
+	v := 0
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0 {
+			v |= 1
+		}
+		r.pos++
+	}
+	return v, nil
+}
+
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+const (
+	modeTerminator = 0x0
+	modeNumeric    = 0x1
+	modeAlnum      = 0x2
+	modeAppend     = 0x3
+	modeByte       = 0x4
+	modeFNC1First  = 0x5
+	modeECI        = 0x7
+	modeKanji      = 0x8
+	modeFNC1Second = 0x9
+)
+
+// charCountBits[mode][versionRange] for versions 1-9, 10-26 and 27-40.
+var charCountBits = map[int][3]int{
+	modeNumeric: {10, 12, 14},
+	modeAlnum:   {9, 11, 13},
+	modeByte:    {8, 16, 16},
+	modeKanji:   {8, 10, 12},
+}
+
+func decodeSegments(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	size := 0
+	if version >= 27 {
+		size = 2
+	} else if version >= 10 {
+		size = 1
+	}
+
+	var out strings.Builder
+	for r.available() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case modeTerminator:
+			return out.String(), nil
+		case modeFNC1First:
+			continue
+		case modeFNC1Second:
+			if _, err := r.read(8); err != nil {
+				return "", err
+			}
+			continue
+		case modeAppend:
+			if _, err := r.read(16); err != nil {
+				return "", err
+			}
+			continue
+		case modeECI:
+			if err := skipECI(r); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		bitsFor, ok := charCountBits[mode]
+		if !ok || mode == modeKanji {
+			return "", fmt.Errorf("%w: %#x", errUnsupported, mode)
+		}
+		count, err := r.read(bitsFor[size])
+		if err != nil {
+			return "", err
+		}
+
+		switch mode {
+		case modeNumeric:
+			err = readNumeric(r, count, &out)
+		case modeAlnum:
+			err = readAlnum(r, count, &out)
+		case modeByte:
+			err = readBytes(r, count, &out)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return out.String(), nil
+}
+
+func skipECI(r *bitReader) error {
+	first, err := r.read(8)
+	if err != nil {
+		return err
+	}
+	switch {
+	case first&0x80 == 0:
+	case first&0xC0 == 0x80:
+		_, err = r.read(8)
+	case first&0xE0 == 0xC0:
+		_, err = r.read(16)
+	default:
+		err = fmt.Errorf("%w: bad ECI designator", errUnsupported)
+	}
+	return err
+}
+
+func readNumeric(r *bitReader, count int, out *strings.Builder) error {
+	for count > 0 {
+		digits := min(count, 3)
+		nbits := []int{0, 4, 7, 10}[digits]
+		v, err := r.read(nbits)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%0*d", digits, v)
+		count -= digits
+	}
+	return nil
+}
+
+func readAlnum(r *bitReader, count int, out *strings.Builder) error {
+	for count >= 2 {
+		v, err := r.read(11)
+		if err != nil {
+			return err
+		}
+		if v/45 >= len(alphanumeric) {
+			return errTruncated
+		}
+		out.WriteByte(alphanumeric[v/45])
+		out.WriteByte(alphanumeric[v%45])
+		count -= 2
+	}
+	if count == 1 {
+		v, err := r.read(6)
+		if err != nil {
+			return err
+		}
+		if v >= len(alphanumeric) {
+			return errTruncated
+		}
+		out.WriteByte(alphanumeric[v])
+	}
+	return nil
+}
+
+// readBytes appends a byte segment, treating it as UTF-8 when valid and as
+// ISO-8859-1 (the QR default) otherwise.
+func readBytes(r *bitReader, count int, out *strings.Builder) error {
+	buf := make([]byte, count)
+	for i := range buf {
+		v, err := r.read(8)
+		if err != nil {
+			return err
+		}
+		buf[i] = byte(v)
+	}
+	if utf8.Valid(buf) {
+		out.Write(buf)
+		return nil
+	}
+	for _, b := range buf {
+		out.WriteRune(rune(b))
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package qrcode
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+var errNotFound = errors.New("no QR code found")
+
+type point struct{ x, y float64 }
+
+func distance(a, b point) float64 { return math.Hypot(a.x-b.x, a.y-b.y) }
+
+// finderPattern is a candidate center of one of the three corner squares.
+type finderPattern struct {
+	point
+	moduleSize float64
+	count      int
+}
+
+// finderFinder scans a bitMatrix for the 1:1:3:1:1 finder pattern runs.
+type finderFinder struct {
+	img        *bitMatrix
+	candidates []*finderPattern
+}
+
+func findFinderPatterns(img *bitMatrix) ([]*finderPattern, error) {
+	f := &finderFinder{img: img}
+	for y := 0; y < img.height; y++ {
+		f.scanRow(y)
+	}
+	return f.selectBest()
+}
+
+func (f *finderFinder) scanRow(y int) {
+	var state [5]int
+	current := 0
+	for x := 0; x < f.img.width; x++ {
+		if f.img.get(x, y) {
+			if current&1 == 1 {
+				current++
+			}
+			state[current]++
+			continue
+		}
+		if current&1 == 1 {
+			state[current]++
+			continue
+		}
+		if current < 4 {
+			current++
+			state[current]++
+			continue
+		}
+		if isFinderRatio(state) && f.handleCenter(state, x, y) {
+			state = [5]int{}
+			current = 0
+			continue
+		}
+		state = [5]int{state[2], state[3], state[4], 1, 0}
+		current = 3
+	}
+	if isFinderRatio(state) {
+		f.handleCenter(state, f.img.width, y)
+	}
+}
+
+func isFinderRatio(state [5]int) bool {
+	total := 0
+	for _, c := range state {
+		if c == 0 {
+			return false
+		}
+		total += c
+	}
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	variance := module / 2
+	return math.Abs(module-float64(state[0])) < variance &&
+		math.Abs(module-float64(state[1])) < variance &&
+		math.Abs(3*module-float64(state[2])) < 3*variance &&
+		math.Abs(module-float64(state[3])) < variance &&
+		math.Abs(module-float64(state[4])) < variance
+}
+
+func centerFromEnd(state [5]int, end int) float64 {
+	return float64(end-state[4]-state[3]) - float64(state[2])/2
+}
+
+func (f *finderFinder) handleCenter(state [5]int, endX, y int) bool {
+	total := state[0] + state[1] + state[2] + state[3] + state[4]
+	cx := centerFromEnd(state, endX)
+	cy, ok := f.crossCheck(int(cx), y, state[2], total, true)
+	if !ok {
+		return false
+	}
+	cx, ok = f.crossCheck(int(cx), int(cy), state[2], total, false)
+	if !ok {
+		return false
+	}
+
+	size := float64(total) / 7
+	for _, c := range f.candidates {
+		if math.Abs(cy-c.y) <= size && math.Abs(cx-c.x) <= size &&
+			(math.Abs(size-c.moduleSize) <= 1 || math.Abs(size-c.moduleSize) <= c.moduleSize) {
+			n := float64(c.count)
+			c.x = (n*c.x + cx) / (n + 1)
+			c.y = (n*c.y + cy) / (n + 1)
+			c.moduleSize = (n*c.moduleSize + size) / (n + 1)
+			c.count++
+			return true
+		}
+	}
+	f.candidates = append(f.candidates, &finderPattern{point: point{cx, cy}, moduleSize: size, count: 1})
+	return true
+}
+
+// crossCheck re-measures the pattern along a column (vertical) or row
+// through (x, y) and returns the refined center coordinate on that axis.
+func (f *finderFinder) crossCheck(x, y, maxCount, originalTotal int, vertical bool) (float64, bool) {
+	pos, limit := x, f.img.width
+	at := func(p int) bool { return f.img.get(p, y) }
+	if vertical {
+		pos, limit = y, f.img.height
+		at = func(p int) bool { return f.img.get(x, p) }
+	}
+
+	var state [5]int
+	i := pos
+	for ; i >= 0 && at(i); i-- {
+		state[2]++
+	}
+	if i < 0 {
+		return 0, false
+	}
+	for ; i >= 0 && !at(i) && state[1] <= maxCount; i-- {
+		state[1]++
+	}
+	if i < 0 || state[1] > maxCount {
+		return 0, false
+	}
+	for ; i >= 0 && at(i) && state[0] <= maxCount; i-- {
+		state[0]++
+	}
+	if state[0] > maxCount {
+		return 0, false
+	}
+
+	i = pos + 1
+	for ; i < limit && at(i); i++ {
+		state[2]++
+	}
+	if i == limit {
+		return 0, false
+	}
+	for ; i < limit && !at(i) && state[3] < maxCount; i++ {
+		state[3]++
+	}
+	if i == limit || state[3] >= maxCount {
+		return 0, false
+	}
+	for ; i < limit && at(i) && state[4] < maxCount; i++ {
+		state[4]++
+	}
+	if state[4] >= maxCount {
+		return 0, false
+	}
+
+	total := state[0] + state[1] + state[2] + state[3] + state[4]
+	if 5*abs(total-originalTotal) >= 2*originalTotal || !isFinderRatio(state) {
+		return 0, false
+	}
+	return centerFromEnd(state, i), true
+}
+
+// selectBest picks the three candidates that best form the right isosceles
+// triangle described by the finder patterns of a QR code.
+func (f *finderFinder) selectBest() ([]*finderPattern, error) {
+	cands := f.candidates
+	if len(cands) < 3 {
+		return nil, errNotFound
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].count > cands[j].count })
+	if len(cands) > 12 {
+		cands = cands[:12]
+	}
+
+	var best []*finderPattern
+	bestScore := math.Inf(1)
+	for i := 0; i < len(cands); i++ {
+		for j := i + 1; j < len(cands); j++ {
+			for k := j + 1; k < len(cands); k++ {
+				a, b, c := cands[i], cands[j], cands[k]
+				lo := min(a.moduleSize, b.moduleSize, c.moduleSize)
+				hi := max(a.moduleSize, b.moduleSize, c.moduleSize)
+				if hi > 1.5*lo {
+					continue
+				}
+				sides := []float64{distance(a.point, b.point), distance(b.point, c.point), distance(a.point, c.point)}
+				sort.Float64s(sides)
+				if sides[0] < 7*lo {
+					continue
+				}
+				score := math.Abs(sides[2]*sides[2]-sides[0]*sides[0]-sides[1]*sides[1])/(sides[2]*sides[2]) +
+					math.Abs(sides[1]-sides[0])/sides[1]
+				if score < bestScore {
+					best, bestScore = []*finderPattern{a, b, c}, score
+				}
+			}
+		}
+	}
+	if best == nil || bestScore > 0.5 {
+		return nil, errNotFound
+	}
+	return orderPatterns(best), nil
+}
+
+// orderPatterns returns the patterns as bottom-left, top-left, top-right.
+func orderPatterns(p []*finderPattern) []*finderPattern {
+	d01 := distance(p[0].point, p[1].point)
+	d12 := distance(p[1].point, p[2].point)
+	d02 := distance(p[0].point, p[2].point)
+
+	var a, b, c *finderPattern
+	switch {
+	case d12 >= d01 && d12 >= d02:
+		b, a, c = p[0], p[1], p[2]
+	case d02 >= d01 && d02 >= d12:
+		b, a, c = p[1], p[0], p[2]
+	default:
+		b, a, c = p[2], p[0], p[1]
+	}
+	if (c.x-b.x)*(a.y-b.y)-(c.y-b.y)*(a.x-b.x) < 0 {
+		a, c = c, a
+	}
+	return []*finderPattern{a, b, c}
+}
+
+// detect locates the symbol and samples it into a module grid.
+func detect(img *bitMatrix) (*bitMatrix, error) {
+	patterns, err := findFinderPatterns(img)
+	if err != nil {
+		return nil, err
+	}
+	bl, tl, tr := patterns[0], patterns[1], patterns[2]
+
+	module := (bl.moduleSize + tl.moduleSize + tr.moduleSize) / 3
+	dim := int(math.Round((distance(tl.point, tr.point)/module+distance(tl.point, bl.point)/module)/2)) + 7
+	switch dim & 3 {
+	case 0:
+		dim++
+	case 2:
+		dim--
+	case 3:
+		return nil, errNotFound
+	}
+	if dim < 21 {
+		return nil, errNotFound
+	}
+
+	version := (dim - 17) / 4
+	far := float64(dim) - 3.5
+	src := [4]point{{3.5, 3.5}, {far, 3.5}, {far, far}, {3.5, far}}
+	br := point{tr.x - tl.x + bl.x, tr.y - tl.y + bl.y}
+	dst := [4]point{tl.point, tr.point, br, bl.point}
+
+	if version >= 2 {
+		// The bottom-right alignment pattern sits 3 modules in from the corner.
+		ratio := 1 - 3/(far-3.5)
+		est := point{tl.x + ratio*(br.x-tl.x), tl.y + ratio*(br.y-tl.y)}
+		for _, allowance := range []float64{4, 8, 16} {
+			if ap, ok := findAlignment(img, est, module, allowance*module); ok {
+				src[2] = point{far - 3, far - 3}
+				dst[2] = ap
+				break
+			}
+		}
+	}
+
+	h, ok := perspective(src, dst)
+	if !ok {
+		return nil, errNotFound
+	}
+	return sample(img, h, dim)
+}
+
+// findAlignment searches a square window around est for the 1:1:1
+// white-black-white runs through the center of an alignment pattern.
+func findAlignment(img *bitMatrix, est point, module, radius float64) (point, bool) {
+	x0, x1 := max(0, int(est.x-radius)), min(img.width-1, int(est.x+radius))
+	y0, y1 := max(0, int(est.y-radius)), min(img.height-1, int(est.y+radius))
+	if x1-x0 < int(3*module) || y1-y0 < int(3*module) {
+		return point{}, false
+	}
+
+	ratioOK := func(state [3]int) bool {
+		for _, c := range state {
+			if math.Abs(module-float64(c)) >= module/2 {
+				return false
+			}
+		}
+		return true
+	}
+
+	var best point
+	bestDist := math.Inf(1)
+	for y := y0; y <= y1; y++ {
+		var state [3]int
+		current := 0
+		for x := x0; x <= x1+1; x++ {
+			dark := x > x1 || img.get(x, y)
+			if (current == 1) == dark {
+				state[current]++
+				continue
+			}
+			if current == 0 {
+				current = 1
+				state[1] = 1
+				continue
+			}
+			if current == 1 {
+				current = 2
+				state[2] = 1
+				continue
+			}
+			// Closing a white-black-white run.
+			if ratioOK(state) {
+				cx := float64(x-state[2]) - float64(state[1])/2
+				if cy, ok := alignmentVertical(img, int(cx), y, module); ok && isAlignmentAt(img, point{cx, cy}, module) {
+					p := point{cx, cy}
+					if d := distance(p, est); d < bestDist {
+						best, bestDist = p, d
+					}
+				}
+			}
+			state = [3]int{state[2], 1, 0}
+			current = 1
+		}
+	}
+	return best, !math.IsInf(bestDist, 1)
+}
+
+func alignmentVertical(img *bitMatrix, x, y int, module float64) (float64, bool) {
+	maxCount := int(2 * module)
+	var state [3]int
+	i := y
+	for ; i >= 0 && img.get(x, i) && state[1] <= maxCount; i-- {
+		state[1]++
+	}
+	for ; i >= 0 && !img.get(x, i) && state[0] <= maxCount; i-- {
+		state[0]++
+	}
+	if i < 0 || state[0] > maxCount {
+		return 0, false
+	}
+	i = y + 1
+	for ; i < img.height && img.get(x, i) && state[1] <= maxCount; i++ {
+		state[1]++
+	}
+	for ; i < img.height && !img.get(x, i) && state[2] <= maxCount; i++ {
+		state[2]++
+	}
+	if i == img.height || state[2] > maxCount {
+		return 0, false
+	}
+	for _, c := range state {
+		if math.Abs(module-float64(c)) >= module/2 {
+			return 0, false
+		}
+	}
+	return float64(i-state[2]) - float64(state[1])/2, true
+}
+
+// isAlignmentAt checks the 5x5 module ring structure around p, allowing a
+// couple of misread modules.
+func isAlignmentAt(img *bitMatrix, p point, module float64) bool {
+	mismatches := 0
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			x := int(p.x + float64(dx)*module)
+			y := int(p.y + float64(dy)*module)
+			if x < 0 || y < 0 || x >= img.width || y >= img.height {
+				return false
+			}
+			ring := max(abs(dx), abs(dy))
+			if img.get(x, y) != (ring != 1) {
+				mismatches++
+			}
+		}
+	}
+	return mismatches <= 2
+}
+
+// transform maps module coordinates to image coordinates.
+type transform [8]float64
+
+// perspective solves the eight-unknown linear system defined by four point
+// correspondences between module space and the image.
+func perspective(src, dst [4]point) (transform, bool) {
+	var m [8][9]float64
+	for i := 0; i < 4; i++ {
+		u, v, x, y := src[i].x, src[i].y, dst[i].x, dst[i].y
+		m[2*i] = [9]float64{u, v, 1, 0, 0, 0, -u * x, -v * x, x}
+		m[2*i+1] = [9]float64{0, 0, 0, u, v, 1, -u * y, -v * y, y}
+	}
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for r := col + 1; r < 8; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-9 {
+			return transform{}, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for r := 0; r < 8; r++ {
+			if r == col {
+				continue
+			}
+			factor := m[r][col] / m[col][col]
+			for c := col; c < 9; c++ {
+				m[r][c] -= factor * m[col][c]
+			}
+		}
+	}
+	var h transform
+	for i := range h {
+		h[i] = m[i][8] / m[i][i]
+	}
+	return h, true
+}
+
+func (h transform) apply(u, v float64) point {
+	w := h[6]*u + h[7]*v + 1
+	return point{(h[0]*u + h[1]*v + h[2]) / w, (h[3]*u + h[4]*v + h[5]) / w}
+}
+
+// sample reads the module at the center of each grid cell.
+func sample(img *bitMatrix, h transform, dim int) (*bitMatrix, error) {
+	grid := newBitMatrix(dim, dim)
+	for y := 0; y < dim; y++ {
+		for x := 0; x < dim; x++ {
+			p := h.apply(float64(x)+0.5, float64(y)+0.5)
+			px, py := int(math.Floor(p.x)), int(math.Floor(p.y))
+			if px < -1 || py < -1 || px > img.width || py > img.height {
+				return nil, errNotFound
+			}
+			px = min(max(px, 0), img.width-1)
+			py = min(max(py, 0), img.height-1)
+			grid.set(x, y, img.get(px, py))
+		}
+	}
+	return grid, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
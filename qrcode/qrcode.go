@@ -0,0 +1,46 @@
+// Package qrcode decodes QR codes from photos and scans of printed receipts.
+//
+// It is a pure-Go, offline decoder built on the standard image packages;
+// PNG and JPEG inputs are supported out of the box.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+var (
+	ErrNotFound     = errors.New("no QR code found in image")
+	ErrUnreadable   = errors.New("QR code found but could not be decoded")
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// Decode finds a QR code in img and returns its text content.
+func Decode(img image.Image) (string, error) {
+	bits := binarize(img)
+
+	grid, err := detect(bits)
+	if err != nil {
+		return "", ErrNotFound
+	}
+
+	text, err := decodeGrid(grid)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnreadable, err)
+	}
+	return text, nil
+}
+
+// DecodeReader decodes a PNG or JPEG image and returns the QR code content.
+func DecodeReader(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidImage, err)
+	}
+	return Decode(img)
+}
@@ -0,0 +1,240 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"math/rand"
+	"testing"
+
+	encoder "github.com/skip2/go-qrcode"
+)
+
+const nfceURL = "http://nfe.sefaz.ba.gov.br/servicos/nfce/modulos/geral/NFCEC_consulta_chave_acesso.aspx?p=29251106057223031484650080003212191080407665|2|1|1|3A5B7C9D0E1F2A3B4C5D6E7F8091A2B3C4D5E6F7"
+
+func encode(t *testing.T, content string, version int, level encoder.RecoveryLevel) image.Image {
+	t.Helper()
+	var (
+		q   *encoder.QRCode
+		err error
+	)
+	if version > 0 {
+		q, err = encoder.NewWithForcedVersion(content, version, level)
+	} else {
+		q, err = encoder.New(content, level)
+	}
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return q.Image(-6)
+}
+
+func TestDecodeVersionsAndLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		version int
+		level   encoder.RecoveryLevel
+	}{
+		{name: "v1_numeric", content: "0123456789", version: 1, level: encoder.Low},
+		{name: "v2_alnum", content: "HTTP://BRISA.EXAMPLE/ABC", version: 2, level: encoder.Medium},
+		{name: "v5_quartile", content: "https://example.com/receipt?id=42", version: 5, level: encoder.High},
+		{name: "v7_version_info", content: "brisa", version: 7, level: encoder.High},
+		{name: "nfce_auto", content: nfceURL, level: encoder.Medium},
+		{name: "v15_latin", content: "Destinatário é São Paulo", version: 15, level: encoder.Highest},
+		{name: "v27_large", content: string(bytes.Repeat([]byte("brisa-"), 80)), version: 27, level: encoder.Low},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(encode(t, tt.content, tt.version, tt.level))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got != tt.content {
+				t.Errorf("Decode() = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestDecodePhotoLike(t *testing.T) {
+	src := encode(t, nfceURL, 0, encoder.Medium)
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{name: "rotated_90", img: warp(src, math.Pi/2, 0, 0)},
+		{name: "rotated_12_degrees", img: warp(src, 12*math.Pi/180, 0, 0)},
+		{name: "sheared_and_noisy", img: warp(src, -0.1, 0.08, 20)},
+		{name: "mirrored", img: mirror(src)},
+		{name: "jpeg", img: jpegRoundTrip(t, warp(src, 0.2, 0, 10))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.img)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got != nfceURL {
+				t.Errorf("Decode() = %q, want %q", got, nfceURL)
+			}
+		})
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, encode(t, nfceURL, 0, encoder.Medium)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeReader(&buf)
+	if err != nil {
+		t.Fatalf("DecodeReader() error = %v", err)
+	}
+	if got != nfceURL {
+		t.Errorf("DecodeReader() = %q", got)
+	}
+
+	if _, err := DecodeReader(bytes.NewReader([]byte("not an image"))); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("DecodeReader(garbage) error = %v, want %v", err, ErrInvalidImage)
+	}
+}
+
+func TestDecodeNoCode(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 200, 200))
+	draw.Draw(blank, blank.Bounds(), image.White, image.Point{}, draw.Src)
+	if _, err := Decode(blank); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Decode(blank) error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestVersionTables(t *testing.T) {
+	known := map[int]int{1: 26, 2: 44, 7: 196, 10: 346, 21: 1156, 40: 3706}
+	for v, want := range known {
+		if got := rawCodewords(v); got != want {
+			t.Errorf("rawCodewords(%d) = %d, want %d", v, got, want)
+		}
+	}
+	for v := 1; v <= 40; v++ {
+		for level := ecLow; level <= ecHigh; level++ {
+			if data := rawCodewords(v) - ecBlocks[level][v]*ecCodewordsPerBlock[level][v]; data <= 0 {
+				t.Errorf("version %d level %d has %d data codewords", v, level, data)
+			}
+		}
+	}
+	if got := alignmentPositions(32); len(got) != 6 || got[1] != 34 || got[5] != 138 {
+		t.Errorf("alignmentPositions(32) = %v", got)
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const numEC = 16
+	data := make([]byte, 40)
+	r.Read(data)
+	block := rsEncode(data, numEC)
+
+	corrupted := append([]byte(nil), block...)
+	for _, i := range r.Perm(len(block))[:numEC/2] {
+		corrupted[i] ^= byte(1 + r.Intn(255))
+	}
+	if err := rsCorrect(corrupted, numEC); err != nil {
+		t.Fatalf("rsCorrect() error = %v", err)
+	}
+	if !bytes.Equal(corrupted, block) {
+		t.Error("rsCorrect() did not restore the block")
+	}
+
+	for _, i := range r.Perm(len(block))[:numEC/2+2] {
+		block[i] ^= byte(1 + r.Intn(255))
+	}
+	if err := rsCorrect(block, numEC); err == nil {
+		t.Error("rsCorrect() expected error beyond capacity")
+	}
+}
+
+// rsEncode appends numEC Reed-Solomon codewords to data.
+func rsEncode(data []byte, numEC int) []byte {
+	gen := []byte{1}
+	for i := 0; i < numEC; i++ {
+		next := make([]byte, len(gen)+1)
+		for j, g := range gen {
+			next[j] ^= g
+			next[j+1] ^= gfMul(g, gfExp[i])
+		}
+		gen = next
+	}
+	rem := make([]byte, numEC)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[numEC-1] = 0
+		for j := range rem {
+			rem[j] ^= gfMul(gen[j+1], factor)
+		}
+	}
+	return append(append([]byte(nil), data...), rem...)
+}
+
+// warp rotates and shears src onto a larger gray canvas, adding noise,
+// to approximate a hand-held photo.
+func warp(src image.Image, angle, shear float64, noise int) image.Image {
+	sb := src.Bounds()
+	size := int(float64(max(sb.Dx(), sb.Dy())) * 1.8)
+	dst := image.NewGray(image.Rect(0, 0, size, size))
+	r := rand.New(rand.NewSource(2))
+
+	cx, cy := float64(size)/2, float64(size)/2
+	sx, sy := float64(sb.Dx())/2, float64(sb.Dy())/2
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			u := cos*dx + sin*dy
+			v := -sin*dx + cos*dy
+			u -= shear * v
+			level := 200
+			if px, py := int(u+sx), int(v+sy); px >= 0 && py >= 0 && px < sb.Dx() && py < sb.Dy() {
+				level = int(color.GrayModel.Convert(src.At(sb.Min.X+px, sb.Min.Y+py)).(color.Gray).Y)
+				level = 30 + level*190/255
+			}
+			if noise > 0 {
+				level += r.Intn(2*noise+1) - noise
+			}
+			dst.SetGray(x, y, color.Gray{Y: uint8(min(max(level, 0), 255))})
+		}
+	}
+	return dst
+}
+
+func mirror(src image.Image) image.Image {
+	b := src.Bounds()
+	dst := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(b.Max.X-1-(x-b.Min.X), y, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+func jpegRoundTrip(t *testing.T, img image.Image) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 70}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package qrcode
+
+import "errors"
+
+var errTooManyErrors = errors.New("too many errors to correct")
+
+// GF(256) tables for the QR primitive polynomial x^8+x^4+x^3+x^2+1.
+var gfExp, gfLog = func() ([512]byte, [256]int) {
+	var exp [512]byte
+	var log [256]int
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfEval evaluates a polynomial with coefficients stored low degree first.
+func gfEval(poly []byte, x byte) byte {
+	var y byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ poly[i]
+	}
+	return y
+}
+
+// rsCorrect fixes up to numEC/2 corrupted codewords of block in place.
+// The block holds data followed by numEC error correction codewords,
+// most significant coefficient first.
+func rsCorrect(block []byte, numEC int) error {
+	n := len(block)
+
+	syndromes := make([]byte, numEC)
+	clean := true
+	for i := range syndromes {
+		var s byte
+		for _, c := range block {
+			s = gfMul(s, gfExp[i]) ^ c
+		}
+		syndromes[i] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return nil
+	}
+
+	// Berlekamp-Massey: find the error locator polynomial.
+	locator, prev := []byte{1}, []byte{1}
+	degree, shift, lastDisc := 0, 1, byte(1)
+	for k := 0; k < numEC; k++ {
+		disc := syndromes[k]
+		for i := 1; i <= degree && i < len(locator); i++ {
+			disc ^= gfMul(locator[i], syndromes[k-i])
+		}
+		if disc == 0 {
+			shift++
+			continue
+		}
+
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+		coef := gfDiv(disc, lastDisc)
+		for i, p := range prev {
+			next[i+shift] ^= gfMul(coef, p)
+		}
+
+		if 2*degree <= k {
+			degree = k + 1 - degree
+			prev, lastDisc, shift = locator, disc, 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	if degree > numEC/2 {
+		return errTooManyErrors
+	}
+
+	// Error evaluator: syndromes * locator mod x^numEC.
+	evaluator := make([]byte, numEC)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+
+	// Formal derivative of the locator (odd terms only in GF(2^m)).
+	derivative := make([]byte, len(locator))
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+
+	// Chien search and Forney's algorithm.
+	found := 0
+	for k := 0; k < n; k++ {
+		power := n - 1 - k
+		xInv := gfExp[(255-power%255)%255]
+		if gfEval(locator, xInv) != 0 {
+			continue
+		}
+		denom := gfEval(derivative, xInv)
+		if denom == 0 {
+			return errTooManyErrors
+		}
+		block[k] ^= gfMul(gfExp[power%255], gfDiv(gfEval(evaluator, xInv), denom))
+		found++
+	}
+	if found != degree {
+		return errTooManyErrors
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package qrcode
+
+import "math/bits"
+
+// ecLevel is the error correction level, indexed in the order used by the
+// tables below (L, M, Q, H).
+type ecLevel int
+
+const (
+	ecLow ecLevel = iota
+	ecMedium
+	ecQuartile
+	ecHigh
+)
+
+// ecLevelFromBits maps the two format-information bits to a level.
+var ecLevelFromBits = [4]ecLevel{ecMedium, ecLow, ecHigh, ecQuartile}
+
+// ecCodewordsPerBlock[level][version] from ISO/IEC 18004 table 9.
+var ecCodewordsPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// ecBlocks[level][version] from ISO/IEC 18004 table 9.
+var ecBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+const (
+	formatMask     = 0x5412
+	formatPoly     = 0x537
+	versionPoly    = 0x1F25
+	maxBitDistance = 3
+)
+
+func dimensionForVersion(version int) int { return 17 + 4*version }
+
+// alignmentPositions returns the row/column centers of the alignment patterns.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, dimensionForVersion(version)-7; i > 0; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// rawCodewords returns the number of codewords (data + EC) a version holds.
+func rawCodewords(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		n := version/7 + 2
+		modules -= (25*n-10)*n - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+	return modules / 8
+}
+
+// bch returns data shifted left with its BCH remainder appended.
+func bch(data, poly int) int {
+	degree := bits.Len(uint(poly)) - 1
+	rem := data << degree
+	for bits.Len(uint(rem)) > degree {
+		rem ^= poly << (bits.Len(uint(rem)) - 1 - degree)
+	}
+	return data<<degree | rem
+}
+
+// decodeFormat returns the EC level and mask encoded in either copy of the
+// format information, tolerating up to maxBitDistance flipped bits.
+func decodeFormat(copy1, copy2 int) (ecLevel, int, bool) {
+	best, bestDist := 0, maxBitDistance+1
+	for data := 0; data < 32; data++ {
+		code := bch(data, formatPoly) ^ formatMask
+		for _, read := range []int{copy1, copy2} {
+			if d := bits.OnesCount(uint(code ^ read)); d < bestDist {
+				best, bestDist = data, d
+			}
+		}
+	}
+	if bestDist > maxBitDistance {
+		return 0, 0, false
+	}
+	return ecLevelFromBits[best>>3], best & 7, true
+}
+
+// decodeVersion returns the version encoded in an 18-bit version block.
+func decodeVersion(read int) (int, bool) {
+	best, bestDist := 0, maxBitDistance+1
+	for v := 7; v <= 40; v++ {
+		if d := bits.OnesCount(uint(bch(v, versionPoly) ^ read)); d < bestDist {
+			best, bestDist = v, d
+		}
+	}
+	return best, bestDist <= maxBitDistance
+}
@@ -63,6 +63,31 @@ func CollectLabelValues(sel *goquery.Selection, cache map[*html.Node]string) map
 	return values
 }
 
+// CollectTableRows extracts the rows of a table whose first row holds the
+// column labels and whose following rows hold one span per column. Each row
+// maps a column label to its value.
+func CollectTableRows(table *goquery.Selection, cache map[*html.Node]string) []map[string]string {
+	var columns []string
+	var rows []map[string]string
+	table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		cells := tr.ChildrenFiltered("td, th")
+		if columns == nil {
+			cells.Each(func(_ int, td *goquery.Selection) {
+				columns = append(columns, CachedText(td.Find("label").First(), cache))
+			})
+			return
+		}
+		row := map[string]string{}
+		cells.Each(func(i int, td *goquery.Selection) {
+			if i < len(columns) && columns[i] != "" {
+				row[columns[i]] = CachedText(td.Find("span").First(), cache)
+			}
+		})
+		rows = append(rows, row)
+	})
+	return rows
+}
+
 // CachedText returns normalized text, using cache for efficiency.
 func CachedText(sel *goquery.Selection, cache map[*html.Node]string) string {
 	if sel.Length() == 0 {
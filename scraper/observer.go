@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Stage identifies a step of a portal fetch.
+type Stage string
+
+const (
+	StageLoadPage     Stage = "load_page"
+	StageFetchCaptcha Stage = "fetch_captcha"
+	StageSolveCaptcha Stage = "solve_captcha"
+	StageSubmitKey    Stage = "submit_key"
+	StageOpenTabs     Stage = "open_tabs"
+	StageLoadProducts Stage = "load_products"
+	StageParse        Stage = "parse"
+	// StageRetry is reported once a failed attempt is about to be retried,
+	// after the backoff wait.
+	StageRetry Stage = "retry"
+)
+
+// Phase tells whether an Event opens or closes a stage.
+type Phase string
+
+const (
+	PhaseStarted  Phase = "started"
+	PhaseFinished Phase = "finished"
+)
+
+// Event reports the progress of a fetch. Finished events carry the stage's
+// duration, the HTTP status of its request when it made one, and the error
+// it failed with, if any.
+type Event struct {
+	Stage      Stage         `json:"stage"`
+	Phase      Phase         `json:"phase"`
+	Attempt    int           `json:"attempt,omitempty"`
+	Time       time.Time     `json:"time"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Err        error         `json:"-"`
+}
+
+// MarshalJSON encodes Err as its message.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	out := struct {
+		event
+		Error string `json:"error,omitempty"`
+	}{event: event(e)}
+	if e.Err != nil {
+		out.Error = e.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// Observer receives fetch events. OnEvent is called synchronously from the
+// fetching goroutine and must not block.
+type Observer interface {
+	OnEvent(Event)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(Event)
+
+func (f ObserverFunc) OnEvent(e Event) { f(e) }
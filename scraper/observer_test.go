@@ -0,0 +1,29 @@
+package scraper
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventJSON(t *testing.T) {
+	e := Event{
+		Stage:      StageSubmitKey,
+		Phase:      PhaseFinished,
+		Attempt:    2,
+		Time:       time.Date(2025, 11, 19, 20, 31, 22, 0, time.UTC),
+		Duration:   1500 * time.Millisecond,
+		StatusCode: 200,
+		Err:        ErrCaptchaInvalid,
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"stage":"submit_key"`, `"attempt":2`, `"status_code":200`, `"error":"invalid captcha solution"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("json = %s, missing %s", data, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/glwbr/brisa/invoice"
+)
+
+var ErrPortalNotSupported = errors.New("portal not supported")
+
+// UnsupportedPortalError reports an access key whose UF has no registered portal.
+type UnsupportedPortalError struct {
+	UF int
+}
+
+func (e *UnsupportedPortalError) Error() string {
+	if state, ok := invoice.StateByCode(e.UF); ok {
+		return fmt.Sprintf("%s: UF %d (%s)", ErrPortalNotSupported, e.UF, state)
+	}
+	return fmt.Sprintf("%s: UF %d", ErrPortalNotSupported, e.UF)
+}
+
+func (e *UnsupportedPortalError) Unwrap() error { return ErrPortalNotSupported }
+
+// Fetcher is a Scraper that can run the whole captcha flow on its own.
+type Fetcher interface {
+	Scraper
+	FetchByAccessKey(ctx context.Context, accessKey string) (*Result, error)
+}
+
+// StatusChecker is implemented by fetchers that can recheck the status of
+// an invoice, e.g. to catch cancellations. The portal is still queried for
+// the access key, but only the status is loaded, not the rest of the invoice.
+type StatusChecker interface {
+	CheckStatus(ctx context.Context, accessKey string) (invoice.Status, []invoice.Event, error)
+}
+
+// Config carries the portal-independent settings passed to a Factory.
+type Config struct {
+	CaptchaSolver CaptchaSolver
+	// Observer, when set, receives the progress events of each fetch.
+	Observer Observer
+	// BaseURL overrides the portal's address, e.g. to target a test fake.
+	BaseURL string
+	// InsecureSkipVerify disables TLS certificate verification. Portals log
+	// a warning when it is set.
+	InsecureSkipVerify bool
+	// WrapTransport, when set, wraps the round tripper portal requests go
+	// through, e.g. to record or replay them.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+}
+
+// Factory builds a Fetcher for a registered portal.
+type Factory func(cfg Config) (Fetcher, error)
+
+type registration struct {
+	portal  invoice.Portal
+	factory Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[int]registration{}
+)
+
+// Register makes a portal available for the given IBGE UF codes.
+// It is meant to be called from a portal package's init function and
+// panics if a UF is already taken.
+func Register(portal invoice.Portal, factory Factory, ufs ...int) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("scraper: Register factory is nil for portal " + portal.String())
+	}
+	for _, uf := range ufs {
+		if existing, ok := registry[uf]; ok {
+			panic(fmt.Sprintf("scraper: UF %d already registered by portal %s", uf, existing.portal))
+		}
+		registry[uf] = registration{portal: portal, factory: factory}
+	}
+}
+
+// unregister removes the registrations of the given UF codes, so tests can
+// undo Register.
+func unregister(ufs ...int) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, uf := range ufs {
+		delete(registry, uf)
+	}
+}
+
+// Portals returns the registered portals and the UF codes each one serves.
+func Portals() map[invoice.Portal][]int {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := map[invoice.Portal][]int{}
+	for uf, reg := range registry {
+		out[reg.portal] = append(out[reg.portal], uf)
+	}
+	for _, ufs := range out {
+		slices.Sort(ufs)
+	}
+	return out
+}
+
+// PortalFor returns the portal serving the given IBGE UF code.
+func PortalFor(uf int) (invoice.Portal, error) {
+	reg, err := lookup(uf)
+	if err != nil {
+		return "", err
+	}
+	return reg.portal, nil
+}
+
+// NewForAccessKey builds the Fetcher for the portal that serves the key's UF.
+func NewForAccessKey(key invoice.AccessKey, cfg Config) (Fetcher, error) {
+	reg, err := lookup(key.UF)
+	if err != nil {
+		return nil, err
+	}
+	return reg.factory(cfg)
+}
+
+// Fetch routes the access key to its portal and runs the full fetch.
+func Fetch(ctx context.Context, accessKey string, cfg Config) (*Result, error) {
+	key, err := invoice.ParseAccessKey(accessKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAccessKey, err)
+	}
+	f, err := NewForAccessKey(key, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return f.FetchByAccessKey(ctx, key.String())
+}
+
+func lookup(uf int) (registration, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	reg, ok := registry[uf]
+	if !ok {
+		return registration{}, &UnsupportedPortalError{UF: uf}
+	}
+	return reg, nil
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/glwbr/brisa/invoice"
+)
+
+type fakeFetcher struct{}
+
+func (f *fakeFetcher) GetCaptcha(context.Context) (*CaptchaChallenge, error) {
+	return &CaptchaChallenge{ID: "1"}, nil
+}
+
+func (f *fakeFetcher) SubmitWithCaptcha(_ context.Context, accessKey, _ string) (*Result, error) {
+	return &Result{Receipt: &invoice.Receipt{Key: accessKey, Portal: invoice.PortalCE}}, nil
+}
+
+func (f *fakeFetcher) FetchByAccessKey(ctx context.Context, accessKey string) (*Result, error) {
+	return f.SubmitWithCaptcha(ctx, accessKey, "")
+}
+
+func TestRegistryRouting(t *testing.T) {
+	Register(invoice.PortalCE, func(Config) (Fetcher, error) {
+		return &fakeFetcher{}, nil
+	}, 23)
+	t.Cleanup(func() { unregister(23) })
+
+	portal, err := PortalFor(23)
+	if err != nil || portal != invoice.PortalCE {
+		t.Fatalf("PortalFor(23) = %q, %v; want %q", portal, err, invoice.PortalCE)
+	}
+
+	key, err := invoice.NewAccessKeyBuilder().
+		UF(23).
+		Period(2025, 1).
+		CNPJ("06057223031484").
+		Number(42).
+		Code("12345678").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	result, err := Fetch(context.Background(), key.String(), Config{})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if result.Receipt.Key != key.String() || result.Receipt.Portal != invoice.PortalCE {
+		t.Errorf("Fetch() receipt = %+v", result.Receipt)
+	}
+}
+
+func TestRegistryUnsupportedUF(t *testing.T) {
+	_, err := PortalFor(35)
+	if !errors.Is(err, ErrPortalNotSupported) {
+		t.Fatalf("PortalFor(35) error = %v, want %v", err, ErrPortalNotSupported)
+	}
+
+	var unsupported *UnsupportedPortalError
+	if !errors.As(err, &unsupported) || unsupported.UF != 35 {
+		t.Fatalf("PortalFor(35) error = %#v, want UF 35", err)
+	}
+	if got, want := err.Error(), "portal not supported: UF 35 (SP)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestFetchInvalidKey(t *testing.T) {
+	_, err := Fetch(context.Background(), "123", Config{})
+	if !errors.Is(err, ErrInvalidAccessKey) || !errors.Is(err, invoice.ErrAccessKeyLength) {
+		t.Errorf("Fetch() error = %v, want %v", err, invoice.ErrAccessKeyLength)
+	}
+}
@@ -0,0 +1,153 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+var ErrRetriesExhausted = errors.New("retries exhausted")
+
+// RetryClass tells a fetch loop what to do after a failed attempt.
+type RetryClass int
+
+const (
+	// Terminal errors are returned as is.
+	Terminal RetryClass = iota
+	// RetryCaptcha means a new captcha should be solved and submitted.
+	RetryCaptcha
+	// RetrySession means the portal session should be discarded and
+	// reloaded before trying again.
+	RetrySession
+)
+
+// RetryPolicy bounds how often a fetch is retried and how long it waits
+// between attempts. The zero value makes a single attempt.
+type RetryPolicy struct {
+	// MaxCaptchaAttempts caps the number of captchas solved and submitted,
+	// counting the first one. Values below 1 mean 1.
+	MaxCaptchaAttempts int
+	// MaxSessionReloads caps how many times an expired session is replaced.
+	MaxSessionReloads int
+
+	// BaseDelay is the wait before the first retry; it doubles on each
+	// further retry up to MaxDelay. Zero disables waiting.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter randomizes each wait by up to this fraction of it, in [0, 1].
+	Jitter float64
+
+	// Classify overrides DefaultClassify.
+	Classify func(err error) RetryClass
+}
+
+// DefaultRetryPolicy returns the policy scrapers use unless configured
+// otherwise.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxCaptchaAttempts: 3,
+		MaxSessionReloads:  1,
+		BaseDelay:          500 * time.Millisecond,
+		MaxDelay:           5 * time.Second,
+		Jitter:             0.25,
+	}
+}
+
+// DefaultClassify retries wrong or expired captchas with a new captcha and
+// expired sessions or server error pages with a new session. Everything
+// else, including context cancellation, is terminal.
+func DefaultClassify(err error) RetryClass {
+	switch {
+	case errors.Is(err, ErrCaptchaInvalid), errors.Is(err, ErrCaptchaExpired):
+		return RetryCaptcha
+	case errors.Is(err, ErrSessionExpired), errors.Is(err, ErrUnexpectedResponse):
+		return RetrySession
+	default:
+		return Terminal
+	}
+}
+
+func (p RetryPolicy) classify(err error) RetryClass {
+	if p.Classify != nil {
+		return p.Classify(err)
+	}
+	return DefaultClassify(err)
+}
+
+// Backoff returns the wait before retry number n (starting at 1).
+func (p RetryPolicy) Backoff(n int) time.Duration {
+	if p.BaseDelay <= 0 || n < 1 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if j := min(max(p.Jitter, 0), 1); j > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * j * float64(d))
+	}
+	return d
+}
+
+// Retrier tracks the attempts of one fetch under a RetryPolicy.
+type Retrier struct {
+	policy   RetryPolicy
+	start    time.Time
+	attempts int
+	reloads  int
+}
+
+// NewRetrier starts tracking a fetch governed by p.
+func NewRetrier(p RetryPolicy) *Retrier {
+	return &Retrier{policy: p, start: time.Now()}
+}
+
+// Attempts returns the number of attempts started so far.
+func (r *Retrier) Attempts() int { return r.attempts }
+
+// Elapsed returns the time since the fetch started.
+func (r *Retrier) Elapsed() time.Duration { return time.Since(r.start) }
+
+// Begin records the start of an attempt.
+func (r *Retrier) Begin() { r.attempts++ }
+
+// Next decides whether to retry after err. It returns the class of retry
+// once the backoff has elapsed, or a terminal error: err itself when it is
+// not retryable, ErrRetriesExhausted wrapping err when the policy is used
+// up, or the context's error.
+func (r *Retrier) Next(ctx context.Context, err error) (RetryClass, error) {
+	class := r.policy.classify(err)
+	switch class {
+	case Terminal:
+		return Terminal, err
+	case RetrySession:
+		if r.reloads >= r.policy.MaxSessionReloads {
+			return Terminal, r.exhausted(err)
+		}
+		r.reloads++
+	}
+	if r.attempts >= max(r.policy.MaxCaptchaAttempts, 1) {
+		return Terminal, r.exhausted(err)
+	}
+
+	if d := r.policy.Backoff(r.attempts); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return Terminal, ctx.Err()
+		case <-t.C:
+		}
+	}
+	return class, nil
+}
+
+func (r *Retrier) exhausted(err error) error {
+	return fmt.Errorf("%w after %d attempts in %s: %w",
+		ErrRetriesExhausted, r.attempts, r.Elapsed().Round(time.Millisecond), err)
+}
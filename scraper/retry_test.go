@@ -0,0 +1,77 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	want := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for n, w := range want {
+		if got := p.Backoff(n); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", n, got, w)
+		}
+	}
+
+	p.Jitter = 0.5
+	for range 100 {
+		if d := p.Backoff(2); d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("Backoff(2) with jitter = %v, want within [100ms, 300ms]", d)
+		}
+	}
+}
+
+func TestDefaultClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want RetryClass
+	}{
+		{fmt.Errorf("submit: %w", ErrCaptchaInvalid), RetryCaptcha},
+		{ErrCaptchaExpired, RetryCaptcha},
+		{ErrSessionExpired, RetrySession},
+		{ErrUnexpectedResponse, RetrySession},
+		{ErrInvoiceNotFound, Terminal},
+		{ErrInvalidAccessKey, Terminal},
+		{context.Canceled, Terminal},
+	}
+	for _, tt := range tests {
+		if got := DefaultClassify(tt.err); got != tt.want {
+			t.Errorf("DefaultClassify(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetrier(t *testing.T) {
+	r := NewRetrier(RetryPolicy{MaxCaptchaAttempts: 2, MaxSessionReloads: 1})
+
+	r.Begin()
+	if class, err := r.Next(context.Background(), ErrSessionExpired); err != nil || class != RetrySession {
+		t.Fatalf("Next(session) = %v, %v", class, err)
+	}
+	r.Begin()
+	_, err := r.Next(context.Background(), ErrCaptchaInvalid)
+	if !errors.Is(err, ErrRetriesExhausted) || !errors.Is(err, ErrCaptchaInvalid) {
+		t.Errorf("Next() after max attempts error = %v, want exhausted wrapping the cause", err)
+	}
+	if r.Attempts() != 2 {
+		t.Errorf("Attempts() = %d, want 2", r.Attempts())
+	}
+
+	if _, err := NewRetrier(RetryPolicy{}).Next(context.Background(), ErrInvoiceNotFound); err != ErrInvoiceNotFound {
+		t.Errorf("Next(terminal) error = %v, want the error unchanged", err)
+	}
+}
+
+func TestRetrierHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := NewRetrier(RetryPolicy{MaxCaptchaAttempts: 3, BaseDelay: time.Hour})
+	r.Begin()
+	if _, err := r.Next(ctx, ErrCaptchaInvalid); !errors.Is(err, context.Canceled) {
+		t.Errorf("Next() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -4,6 +4,7 @@ package scraper
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/glwbr/brisa/invoice"
 )
@@ -27,4 +28,13 @@ type Scraper interface {
 type Result struct {
 	Receipt *invoice.Receipt
 	RawHTML map[string][]byte
+
+	// Attempts is the number of captcha submissions it took, and Elapsed
+	// the total time including retries and captcha solving.
+	Attempts int
+	Elapsed  time.Duration
+
+	// Warnings lists the optional pages that could not be loaded or
+	// parsed. The receipt lacks their data but is otherwise complete.
+	Warnings []error
 }
@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrSnapshotMismatch = errors.New("session snapshot is for another portal")
+
+// SessionSnapshot captures a portal session, its cookies and ASP.NET form
+// state, so that a later process can resume it instead of starting over.
+type SessionSnapshot struct {
+	BaseURL string            `json:"base_url"`
+	Cookies map[string]string `json:"cookies"`
+	// FormState is the state of the portal's entry page, when that was the
+	// last page loaded.
+	FormState *FormState `json:"form_state,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	// ExpiresAt is when the portal drops the session if it stays idle.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the portal has likely dropped the session.
+func (s *SessionSnapshot) Expired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// Resumable is implemented by fetchers whose session can be saved and
+// resumed later.
+type Resumable interface {
+	// Snapshot returns the current session, or nil if there is none.
+	Snapshot() *SessionSnapshot
+	// Restore resumes a session taken by Snapshot. A session the portal
+	// has dropped meanwhile fails with ErrSessionExpired on use.
+	Restore(*SessionSnapshot) error
+}
+
+// FileSessionStore keeps session snapshots as JSON files in Dir, one per
+// name.
+type FileSessionStore struct {
+	Dir string
+}
+
+// Load returns the snapshot saved as name. Missing snapshots fail with an
+// error matching os.ErrNotExist.
+func (s FileSessionStore) Load(name string) (*SessionSnapshot, error) {
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return nil, err
+	}
+	var snap SessionSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", name, err)
+	}
+	return &snap, nil
+}
+
+// Save stores snap as name. The file is only readable by the user, as the
+// cookies grant access to the session.
+func (s FileSessionStore) Save(name string, snap *SessionSnapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(name), data, 0600)
+}
+
+// Delete removes the snapshot saved as name, if any.
+func (s FileSessionStore) Delete(name string) error {
+	err := os.Remove(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s FileSessionStore) path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
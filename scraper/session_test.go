@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	store := FileSessionStore{Dir: t.TempDir() + "/sessions"}
+
+	if _, err := store.Load("ba"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of missing session error = %v, want %v", err, os.ErrNotExist)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	want := &SessionSnapshot{
+		BaseURL:   "https://nfe.sefaz.ba.gov.br",
+		Cookies:   map[string]string{"ASP.NET_SessionId": "abc"},
+		FormState: &FormState{ViewState: "vs", EventValidation: "ev"},
+		CreatedAt: now,
+		ExpiresAt: now.Add(20 * time.Minute),
+	}
+	if err := store.Save("ba", want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := store.Load("ba")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+
+	if err := store.Delete("ba"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete("ba"); err != nil {
+		t.Errorf("Delete() of missing session error = %v", err)
+	}
+	if _, err := store.Load("ba"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() after Delete error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestSessionSnapshotExpired(t *testing.T) {
+	now := time.Now()
+	snap := &SessionSnapshot{ExpiresAt: now.Add(time.Minute)}
+	if snap.Expired(now) {
+		t.Error("Expired() before ExpiresAt = true")
+	}
+	if !snap.Expired(now.Add(time.Minute)) {
+		t.Error("Expired() at ExpiresAt = false")
+	}
+}
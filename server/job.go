@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/glwbr/brisa/internal/http"
 	"github.com/glwbr/brisa/invoice"
 	"github.com/glwbr/brisa/scraper"
 )
@@ -19,15 +20,27 @@ const (
 )
 
 type Job struct {
-	ID        string           `json:"id"`
-	Status    JobStatus        `json:"status"`
-	AccessKey string           `json:"accessKey"`
-	Result    *invoice.Receipt `json:"result,omitempty"`
-	Error     string           `json:"error,omitempty"`
-	CreatedAt time.Time        `json:"createdAt"`
+	ID        string            `json:"id"`
+	Status    JobStatus         `json:"status"`
+	AccessKey string            `json:"accessKey"`
+	Result    *invoice.Receipt  `json:"result,omitempty"`
+	Findings  []invoice.Finding `json:"findings,omitempty"`
+	Cancelled bool              `json:"cancelled,omitempty"` // the result is not spending
+	Warnings  []string          `json:"warnings,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
 
 	Captcha *scraper.CaptchaChallenge `json:"captcha,omitempty"`
 
+	// Stage is the scraper stage in progress and Events the history of
+	// the fetch so far.
+	Stage  scraper.Stage   `json:"stage,omitempty"`
+	Events []scraper.Event `json:"events,omitempty"`
+
+	// HARURL points to the HAR capture of a failed fetch.
+	HARURL string `json:"harUrl,omitempty"`
+
+	har        *http.HARWriter
 	solutionCh chan string
 	mu         sync.Mutex
 }
@@ -103,11 +116,16 @@ func (j *Job) SetRunning() {
 	j.Captcha = nil
 }
 
-func (j *Job) SetCompleted(result *invoice.Receipt) {
+func (j *Job) SetCompleted(result *scraper.Result) {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 	j.Status = StatusCompleted
-	j.Result = result
+	j.Result = result.Receipt
+	j.Findings = invoice.Validate(result.Receipt)
+	j.Cancelled = result.Receipt.Cancelled()
+	for _, w := range result.Warnings {
+		j.Warnings = append(j.Warnings, w.Error())
+	}
 	j.Captcha = nil
 }
 
@@ -117,6 +135,43 @@ func (j *Job) SetFailed(err error) {
 	j.Status = StatusFailed
 	j.Error = err.Error()
 	j.Captcha = nil
+	if j.har != nil && j.har.Len() > 0 {
+		j.HARURL = "/api/invoice-jobs/" + j.ID + "/har"
+	}
+}
+
+// CaptureHAR records the job's portal traffic into h, to be offered for
+// download if the job fails.
+func (j *Job) CaptureHAR(h *http.HARWriter) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.har = h
+}
+
+// HAR returns the traffic capture of a failed job.
+func (j *Job) HAR() (*http.HARWriter, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.har, j.HARURL != ""
+}
+
+// redact hides a value, such as a captcha answer, from the HAR capture.
+func (j *Job) redact(v string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.har != nil {
+		j.har.Redact(v)
+	}
+}
+
+// OnEvent records scraper progress, implementing scraper.Observer.
+func (j *Job) OnEvent(e scraper.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Events = append(j.Events, e)
+	if e.Phase == scraper.PhaseStarted {
+		j.Stage = e.Stage
+	}
 }
 
 func (j *Job) SubmitCaptcha(solution string) {
@@ -3,55 +3,95 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
 	"time"
 
-	"github.com/glwbr/brisa/portal/ba"
+	bhttp "github.com/glwbr/brisa/internal/http"
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/qrcode"
+	"github.com/glwbr/brisa/scraper"
+
+	_ "github.com/glwbr/brisa/portal/ba"
 )
 
+// maxImageSize caps receipt photo uploads.
+const maxImageSize = 10 << 20
+
 // TODO: add a logger
 type Server struct {
-	jobManager *JobManager
+	jobManager    *JobManager
+	scraperConfig scraper.Config
+}
+
+type Option func(*Server)
+
+// WithScraperConfig sets the settings every job's scraper is built with.
+// The captcha solver and observer are always replaced by ones bound to the job.
+func WithScraperConfig(cfg scraper.Config) Option {
+	return func(s *Server) { s.scraperConfig = cfg }
 }
 
-func NewServer() *Server {
+func NewServer(opts ...Option) *Server {
 	s := &Server{
 		jobManager: NewJobManager(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
 	go s.jobManager.CleanupLoop(1*time.Minute, 2*time.Minute)
 	return s
 }
 
-func (s *Server) Start(addr string) error {
+// Handler returns the HTTP API handler.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("POST /api/invoice-jobs", s.handleCreateJob)
 	mux.HandleFunc("GET /api/invoice-jobs/{id}", s.handleGetJob)
 	mux.HandleFunc("POST /api/invoice-jobs/{id}/captcha", s.handleSubmitCaptcha)
+	mux.HandleFunc("GET /api/invoice-jobs/{id}/har", s.handleGetHAR)
 
-	handler := corsMiddleware(mux)
+	return corsMiddleware(mux)
+}
 
+func (s *Server) Start(addr string) error {
 	log.Printf("Server starting on %s", addr)
-	return http.ListenAndServe(addr, handler)
+	return http.ListenAndServe(addr, s.Handler())
 }
 
 func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		AccessKey string `json:"accessKey"`
+	var (
+		key invoice.AccessKey
+		err error
+	)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		key, err = accessKeyFromImage(w, r)
+	} else {
+		var req struct {
+			AccessKey string `json:"accessKey"`
+			QRCode    string `json:"qrCode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		key, err = resolveAccessKey(req.AccessKey, req.QRCode)
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if req.AccessKey == "" {
-		http.Error(w, "accessKey is required", http.StatusBadRequest)
+	if _, err := scraper.PortalFor(key.UF); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
-	job := s.jobManager.CreateJob(req.AccessKey)
+	job := s.jobManager.CreateJob(key.String())
 
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
@@ -59,20 +99,32 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 
 		job.SetRunning()
 
-		solver := NewAsyncSolver(job)
-		scraper, err := ba.New(ba.WithCaptchaSolver(solver))
+		// Portal pages show the key grouped in fours too.
+		har := bhttp.NewHARWriter(key.String(), key.Formatted())
+		job.CaptureHAR(har)
+
+		cfg := s.scraperConfig
+		cfg.CaptchaSolver = NewAsyncSolver(job)
+		cfg.Observer = job
+		cfg.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+			if wrap := s.scraperConfig.WrapTransport; wrap != nil {
+				rt = wrap(rt)
+			}
+			return har.Transport(rt)
+		}
+		f, err := scraper.NewForAccessKey(key, cfg)
 		if err != nil {
 			job.SetFailed(fmt.Errorf("failed to create scraper: %w", err))
 			return
 		}
 
-		result, err := scraper.FetchByAccessKey(ctx, req.AccessKey)
+		result, err := f.FetchByAccessKey(ctx, key.String())
 		if err != nil {
 			job.SetFailed(err)
 			return
 		}
 
-		job.SetCompleted(result.Receipt)
+		job.SetCompleted(result)
 	}()
 
 	w.Header().Set("Content-Type", "application/json")
@@ -81,6 +133,53 @@ func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// accessKeyFromImage decodes the NFC-e QR code in an uploaded receipt photo
+// sent as the "image" field of a multipart form.
+func accessKeyFromImage(w http.ResponseWriter, r *http.Request) (invoice.AccessKey, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageSize)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		return invoice.AccessKey{}, fmt.Errorf("image is required: %w", err)
+	}
+	defer file.Close()
+
+	text, err := qrcode.DecodeReader(file)
+	if err != nil {
+		return invoice.AccessKey{}, fmt.Errorf("read QR code: %w", err)
+	}
+	return resolveAccessKey(r.FormValue("accessKey"), text)
+}
+
+// resolveAccessKey returns the key given directly or embedded in a QR code URL.
+// When both are present they must refer to the same invoice.
+func resolveAccessKey(accessKey, qrCode string) (invoice.AccessKey, error) {
+	if accessKey == "" && qrCode == "" {
+		return invoice.AccessKey{}, errors.New("accessKey or qrCode is required")
+	}
+
+	var key invoice.AccessKey
+	if accessKey != "" {
+		k, err := invoice.ParseAccessKey(accessKey)
+		if err != nil {
+			return invoice.AccessKey{}, fmt.Errorf("invalid accessKey: %w", err)
+		}
+		key = k
+	}
+
+	if qrCode != "" {
+		qr, err := invoice.ParseQRCodeURL(qrCode)
+		if err != nil {
+			return invoice.AccessKey{}, fmt.Errorf("invalid qrCode: %w", err)
+		}
+		if !key.IsZero() && key != qr.AccessKey {
+			return invoice.AccessKey{}, errors.New("accessKey does not match qrCode")
+		}
+		key = qr.AccessKey
+	}
+
+	return key, nil
+}
+
 func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	job, ok := s.jobManager.GetJob(id)
@@ -100,6 +199,23 @@ func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(job)
 }
 
+func (s *Server) handleGetHAR(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobManager.GetJob(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	har, ok := job.HAR()
+	if !ok {
+		http.Error(w, "No HAR available for this job", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "invoice-job-"+job.ID+".har"))
+	har.WriteTo(w)
+}
+
 func (s *Server) handleSubmitCaptcha(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	job, ok := s.jobManager.GetJob(id)
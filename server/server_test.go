@@ -0,0 +1,265 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glwbr/brisa/invoice"
+	"github.com/glwbr/brisa/portal/ba/batest"
+	"github.com/glwbr/brisa/scraper"
+	encoder "github.com/skip2/go-qrcode"
+)
+
+func TestJobFlow(t *testing.T) {
+	portal, err := batest.NewServer(os.DirFS("../testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer portal.Close()
+	portal.FailNext(batest.FailWrongCaptcha)
+	portal.Cancel()
+
+	api := httptest.NewServer(NewServer(WithScraperConfig(scraper.Config{BaseURL: portal.URL})).Handler())
+	defer api.Close()
+
+	resp, err := http.Post(api.URL+"/api/invoice-jobs", "application/json",
+		bytes.NewBufferString(`{"accessKey":"`+portal.AccessKey()+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		JobID string `json:"jobId"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if created.JobID == "" {
+		t.Fatalf("create job: status %d, no job id", resp.StatusCode)
+	}
+
+	// The first answer is rejected by the scripted failure, so the job asks
+	// for a second captcha before completing.
+	for range 2 {
+		waitFor(t, api.URL, created.JobID, StatusWaitingCaptcha)
+		submitCaptcha(t, api.URL, created.JobID, batest.CaptchaAnswer)
+	}
+
+	job := waitFor(t, api.URL, created.JobID, StatusCompleted)
+	if job.Result == nil || job.Result.Key != portal.AccessKey() {
+		t.Fatalf("job result = %+v", job.Result)
+	}
+	if len(job.Result.Items) != 29 {
+		t.Errorf("len(Items) = %d, want 29", len(job.Result.Items))
+	}
+	if !job.Cancelled || job.Result.Status != invoice.StatusCancelled {
+		t.Errorf("Cancelled = %v, Status = %q, want a cancelled result", job.Cancelled, job.Result.Status)
+	}
+
+	if job.Stage != scraper.StageParse {
+		t.Errorf("Stage = %q, want %q", job.Stage, scraper.StageParse)
+	}
+	retried := false
+	for _, e := range job.Events {
+		if e.Stage == scraper.StageRetry {
+			retried = true
+		}
+	}
+	if !retried {
+		t.Errorf("Events has no %s event: %+v", scraper.StageRetry, job.Events)
+	}
+}
+
+func TestFailedJobHAR(t *testing.T) {
+	portal, err := batest.NewServer(os.DirFS("../testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer portal.Close()
+	portal.FailNext(batest.FailNotFound)
+
+	api := httptest.NewServer(NewServer(WithScraperConfig(scraper.Config{BaseURL: portal.URL})).Handler())
+	defer api.Close()
+
+	resp, err := http.Post(api.URL+"/api/invoice-jobs", "application/json",
+		bytes.NewBufferString(`{"accessKey":"`+portal.AccessKey()+`"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		JobID string `json:"jobId"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+
+	waitFor(t, api.URL, created.JobID, StatusWaitingCaptcha)
+	submitCaptcha(t, api.URL, created.JobID, batest.CaptchaAnswer)
+	job := waitFor(t, api.URL, created.JobID, StatusFailed)
+	if job.HARURL == "" {
+		t.Fatal("failed job has no HAR")
+	}
+
+	resp, err = http.Get(api.URL + job.HARURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s status = %d", job.HARURL, resp.StatusCode)
+	}
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				Request struct {
+					Method   string `json:"method"`
+					PostData *struct {
+						Text string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&har); err != nil {
+		t.Fatalf("decode HAR: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) == 0 {
+		t.Fatalf("HAR log = %+v", har.Log)
+	}
+	for _, e := range har.Log.Entries {
+		if pd := e.Request.PostData; pd != nil {
+			if strings.Contains(pd.Text, portal.AccessKey()) || strings.Contains(pd.Text, batest.CaptchaAnswer) {
+				t.Errorf("HAR post data not redacted: %s", pd.Text)
+			}
+		}
+	}
+}
+
+func TestCreateJobUnsupportedPortal(t *testing.T) {
+	api := httptest.NewServer(NewServer().Handler())
+	defer api.Close()
+
+	// A valid São Paulo (UF 35) key: no portal is registered for it.
+	resp, err := http.Post(api.URL+"/api/invoice-jobs", "application/json",
+		bytes.NewBufferString(`{"accessKey":"35251106057223031484650080003212191080407662"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", resp.StatusCode)
+	}
+}
+
+func TestCreateJobFromImage(t *testing.T) {
+	portal, err := batest.NewServer(os.DirFS("../testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer portal.Close()
+
+	api := httptest.NewServer(NewServer(WithScraperConfig(scraper.Config{BaseURL: portal.URL})).Handler())
+	defer api.Close()
+
+	qrURL := "http://nfe.sefaz.ba.gov.br/servicos/nfce/modulos/geral/NFCEC_consulta_chave_acesso.aspx?p=" +
+		portal.AccessKey() + "|2|1|1|3A5B7C9D0E1F"
+	qrPNG, err := encoder.Encode(qrURL, encoder.Medium, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		field      string
+		image      []byte
+		wantStatus int
+	}{
+		{name: "qr_png", field: "image", image: qrPNG, wantStatus: http.StatusOK},
+		{name: "not_image", field: "image", image: []byte("not an image"), wantStatus: http.StatusBadRequest},
+		{name: "missing_image", field: "photo", image: qrPNG, wantStatus: http.StatusBadRequest},
+		{name: "too_large", field: "image", image: make([]byte, maxImageSize+1), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fw, err := mw.CreateFormFile(tt.field, "receipt.png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw.Write(tt.image)
+			mw.Close()
+
+			resp, err := http.Post(api.URL+"/api/invoice-jobs", mw.FormDataContentType(), &body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var created struct {
+				JobID string `json:"jobId"`
+			}
+			json.NewDecoder(resp.Body).Decode(&created)
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			job := waitFor(t, api.URL, created.JobID, StatusWaitingCaptcha)
+			if job.AccessKey != portal.AccessKey() {
+				t.Errorf("AccessKey = %q, want %q", job.AccessKey, portal.AccessKey())
+			}
+		})
+	}
+}
+
+func waitFor(t *testing.T, baseURL, id string, status JobStatus) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(baseURL + "/api/invoice-jobs/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var job Job
+		err = json.NewDecoder(resp.Body).Decode(&job)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return &job
+		}
+		if job.Status == StatusFailed {
+			t.Fatalf("job failed: %s", job.Error)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job did not reach %s", status)
+	return nil
+}
+
+func submitCaptcha(t *testing.T, baseURL, id, solution string) {
+	t.Helper()
+	// The solver may not be listening yet right after the status changes.
+	for range 50 {
+		resp, err := http.Post(baseURL+"/api/invoice-jobs/"+id+"/captcha", "application/json",
+			bytes.NewBufferString(`{"solution":"`+solution+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("captcha submission was never accepted")
+}
@@ -22,6 +22,7 @@ func (s *AsyncSolver) Solve(ctx context.Context, challenge *scraper.CaptchaChall
 		return nil, ctx.Err()
 	case solution := <-s.Job.solutionCh:
 		s.Job.SetRunning()
+		s.Job.redact(solution)
 		return &scraper.CaptchaSolution{
 			Text:        solution,
 			ChallengeID: challenge.ID,